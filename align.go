@@ -0,0 +1,327 @@
+package ctranslate2ffi
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Word alignment parameters.
+const (
+	alignFrameRate    = FrameRate / 2 // encoder positions per second
+	medianFilterWidth = 7             // smoothing of the attention weights
+)
+
+// Alignment is the result of Whisper.Align: a monotonic path through the
+// text tokens and the encoder time positions, 50 per second, found by
+// dynamic time warping over the cross-attention of the alignment heads.
+type Alignment struct {
+	// TextIndices and TimeIndices are the steps of the path: the text
+	// token at TextIndices[i] is aligned with the encoder position at
+	// TimeIndices[i]. The end-of-text token follows the text tokens.
+	TextIndices []int
+	TimeIndices []int
+
+	// TextTokenProbs holds the probability of each text token.
+	TextTokenProbs []float32
+}
+
+// Word is a word of a segment with its position in the audio.
+type Word struct {
+	Start       time.Duration
+	End         time.Duration
+	Text        string
+	Probability float32
+}
+
+// Align aligns text tokens with a single audio window. features holds the
+// mel features or encoder output of the window, startSequence the token ids
+// of the prompt, such as <|startoftranscript|> <|en|> <|transcribe|>, and
+// numFrames the number of mel frames of the window that hold audio rather
+// than padding. medianFilterWidth smooths the attention weights before the
+// alignment; 7 is the usual value. Alignment needs a library that exports
+// ct2_whisper_align.
+func (w *Whisper) Align(features *StorageView, startSequence, textTokens []int, numFrames, medianFilterWidth int) (*Alignment, error) {
+	if w.handle == 0 {
+		return nil, errors.New("whisper model is closed")
+	}
+
+	if !ct2WhisperAlignLoaded {
+		return nil, errors.New("alignment is not supported by this library")
+	}
+
+	shape, err := w.featureShape(features)
+	if err != nil {
+		return nil, err
+	}
+	if shape[0] != 1 {
+		return nil, errors.New("alignment expects a single audio window")
+	}
+
+	if len(startSequence) == 0 || len(textTokens) == 0 {
+		return nil, errors.New("start sequence and text tokens cannot be empty")
+	}
+
+	start := cSizes(startSequence)
+	text := cSizes(textTokens)
+
+	var result Ct2whisperalignmentresult
+	ret := Ct2WhisperAlign(w.handle, features.handle, uintptr(unsafe.Pointer(&start[0])), uint64(len(start)), uintptr(unsafe.Pointer(&text[0])), uint64(len(text)), uint64(numFrames), uint64(medianFilterWidth), &result)
+	runtime.KeepAlive(start)
+	runtime.KeepAlive(text)
+	if ret != 0 {
+		errMsg := Ct2GetLastError()
+		if errMsg == "" {
+			errMsg = "whisper alignment failed"
+		}
+		return nil, errors.New(errMsg)
+	}
+
+	a := &Alignment{
+		TextIndices:    goInts(result.TextTokenIndices, result.NumAlignments),
+		TimeIndices:    goInts(result.TimeIndices, result.NumAlignments),
+		TextTokenProbs: goFloats(result.TextTokenProbs, result.NumTextTokens),
+	}
+
+	Ct2WhisperAlignmentResultFree(&result)
+	return a, nil
+}
+
+// WordTimestamps returns the words of decoded text with their start and end
+// times and probabilities. tokens are text tokens decoded from the window in
+// features, such as the tokens of a segment returned by TranscribeSegments;
+// special tokens among them are ignored. prompt gives the language and task
+// the window was decoded with. numFrames is the number of mel frames of the
+// window that hold audio. Times are relative to the start of the window.
+func (w *Whisper) WordTimestamps(features *StorageView, tokenizer *Tokenizer, prompt WhisperPrompt, tokens []string, numFrames int) ([]Word, error) {
+	words, _, err := w.alignWords(features, tokenizer, prompt, tokens, numFrames)
+	return words, err
+}
+
+// alignWords aligns tokens with the window and splits them into words. It
+// also returns the number of text tokens in each word.
+func (w *Whisper) alignWords(features *StorageView, tokenizer *Tokenizer, prompt WhisperPrompt, tokens []string, numFrames int) ([]Word, []int, error) {
+	if tokenizer == nil {
+		return nil, nil, errors.New("word timestamps need a tokenizer")
+	}
+
+	textTokens := withoutSpecial(tokenizer, tokens)
+	if len(textTokens) == 0 {
+		return nil, nil, nil
+	}
+
+	// The alignment is conditioned on the prompt without previous text and
+	// without <|notimestamps|>
+	prompt.Timestamps = true
+	prompt.InitialPrompt, prompt.PreviousText = nil, nil
+	startTokens, err := prompt.Tokens()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	startIDs, err := tokenIDs(tokenizer, startTokens)
+	if err != nil {
+		return nil, nil, err
+	}
+	textIDs, err := tokenIDs(tokenizer, textTokens)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a, err := w.Align(features, startIDs, textIDs, numFrames, medianFilterWidth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	words, counts := alignmentWords(tokenizer, a, textTokens, prompt.Language)
+	return words, counts, nil
+}
+
+// alignmentWords splits the text tokens aligned by a into words and times
+// them. It also returns the number of text tokens in each word.
+func alignmentWords(tokenizer *Tokenizer, a *Alignment, textTokens []string, language string) ([]Word, []int) {
+	// The time of each text token is where the path first reaches it
+	var jumps []time.Duration
+	for i, t := range a.TextIndices {
+		if i == 0 || t != a.TextIndices[i-1] {
+			jumps = append(jumps, time.Duration(a.TimeIndices[i])*time.Second/alignFrameRate)
+		}
+	}
+	if len(jumps) == 0 {
+		return nil, nil
+	}
+	jumpAt := func(i int) time.Duration {
+		return jumps[min(i, len(jumps)-1)]
+	}
+
+	groups := splitWordTokens(tokenizer, textTokens, language)
+	words := make([]Word, len(groups))
+	counts := make([]int, len(groups))
+	first := 0
+	for i, g := range groups {
+		last := first + len(g)
+
+		var prob float32
+		var n int
+		for j := first; j < last && j < len(a.TextTokenProbs); j++ {
+			prob += a.TextTokenProbs[j]
+			n++
+		}
+		if n > 0 {
+			prob /= float32(n)
+		}
+
+		words[i] = Word{
+			Start:       jumpAt(first),
+			End:         jumpAt(last),
+			Text:        tokenizer.DecodeTokens(g),
+			Probability: prob,
+		}
+		counts[i] = len(g)
+		first = last
+	}
+
+	return words, counts
+}
+
+// segmentWords times the words of the segments decoded from a window and
+// adds them to the segments. tokens holds the tokens of each segment and
+// offset is the position of the window in the audio.
+func (w *Whisper) segmentWords(features *StorageView, tokenizer *Tokenizer, prompt WhisperPrompt, segments []Segment, tokens [][]string, numFrames int, offset time.Duration) error {
+	var all []string
+	counts := make([]int, len(segments))
+	for i, toks := range tokens {
+		text := withoutSpecial(tokenizer, toks)
+		all = append(all, text...)
+		counts[i] = len(text)
+	}
+
+	words, wordCounts, err := w.alignWords(features, tokenizer, prompt, all, numFrames)
+	if err != nil {
+		return err
+	}
+
+	assignWords(segments, counts, words, wordCounts, offset)
+	return nil
+}
+
+// assignWords adds words to the segments they belong to, moving them by
+// offset. counts holds the number of text tokens of each segment and
+// wordCounts that of each word. A word belongs to the segment that holds its
+// first token.
+func assignWords(segments []Segment, counts []int, words []Word, wordCounts []int, offset time.Duration) {
+	seg, segEnd, consumed := 0, counts[0], 0
+	for i, word := range words {
+		for seg < len(segments)-1 && consumed >= segEnd {
+			seg++
+			segEnd += counts[seg]
+		}
+
+		word.Start += offset
+		word.End += offset
+		segments[seg].Words = append(segments[seg].Words, word)
+		consumed += wordCounts[i]
+	}
+}
+
+// withoutSpecial drops the special tokens, such as timestamps, from tokens.
+func withoutSpecial(tokenizer *Tokenizer, tokens []string) []string {
+	text := make([]string, 0, len(tokens))
+	for _, tok := range tokens {
+		if !tokenizer.IsSpecial(tok) {
+			text = append(text, tok)
+		}
+	}
+
+	return text
+}
+
+func tokenIDs(tokenizer *Tokenizer, tokens []string) ([]int, error) {
+	ids := make([]int, len(tokens))
+	for i, tok := range tokens {
+		id, ok := tokenizer.ID(tok)
+		if !ok {
+			return nil, fmt.Errorf("token %q is not in the vocabulary", tok)
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
+
+// Languages written without spaces between words, whose words are split at
+// every character instead.
+var unspacedLanguages = map[string]bool{
+	"zh": true, "ja": true, "th": true, "lo": true, "my": true, "yue": true,
+}
+
+// Punctuation attached to the following and to the preceding word.
+const (
+	prependedPunctuation = "\"'“¿([{-"
+	appendedPunctuation  = "\"'.。,，!！?？:：”)]}、"
+)
+
+// splitWordTokens groups text tokens into words. In most languages a word
+// starts at a token with a leading space; in languages written without
+// spaces every character is a word. Punctuation is attached to the word it
+// belongs to.
+func splitWordTokens(tokenizer *Tokenizer, tokens []string, language string) [][]string {
+	var groups [][]string
+	var pending []string
+	for _, tok := range tokens {
+		pending = append(pending, tok)
+
+		// Wait for the bytes of a character split across tokens
+		text := tokenizer.DecodeTokens(pending)
+		if strings.ContainsRune(text, utf8.RuneError) && len(pending) < utf8.UTFMax {
+			continue
+		}
+
+		spaced := strings.HasPrefix(text, " ")
+		punct := strings.TrimSpace(text) != "" && strings.TrimFunc(strings.TrimSpace(text), unicode.IsPunct) == ""
+		if len(groups) == 0 || unspacedLanguages[language] || spaced || punct {
+			groups = append(groups, pending)
+		} else {
+			groups[len(groups)-1] = append(groups[len(groups)-1], pending...)
+		}
+		pending = nil
+	}
+	if len(pending) > 0 {
+		groups = append(groups, pending)
+	}
+
+	return mergePunctuation(tokenizer, groups)
+}
+
+// mergePunctuation attaches opening punctuation to the next word and closing
+// punctuation to the previous one.
+func mergePunctuation(tokenizer *Tokenizer, groups [][]string) [][]string {
+	var merged [][]string
+	var prefix []string
+	for _, g := range groups {
+		text := tokenizer.DecodeTokens(g)
+		word := strings.TrimSpace(text)
+
+		switch {
+		case len(merged) > 0 && !strings.HasPrefix(text, " ") && word != "" && strings.Contains(appendedPunctuation, word):
+			merged[len(merged)-1] = append(merged[len(merged)-1], g...)
+
+		case word != "" && strings.Contains(prependedPunctuation, word):
+			prefix = append(prefix, g...)
+
+		default:
+			merged = append(merged, append(prefix, g...))
+			prefix = nil
+		}
+	}
+	if len(prefix) > 0 {
+		merged = append(merged, prefix)
+	}
+
+	return merged
+}
@@ -0,0 +1,200 @@
+package ctranslate2ffi
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitWordTokens(t *testing.T) {
+	tok := fixtureTokenizer()
+
+	japanese, err := tok.EncodeTokens("日本")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(japanese) != 6 {
+		t.Fatalf("EncodeTokens(日本) = %q, want 6 byte tokens", japanese)
+	}
+
+	tests := []struct {
+		name     string
+		tokens   []string
+		language string
+		want     [][]string
+	}{
+		{
+			name:     "words start at a leading space",
+			tokens:   []string{"ĠHello", "Ġwor", "ld"},
+			language: "en",
+			want:     [][]string{{"ĠHello"}, {"Ġwor", "ld"}},
+		},
+		{
+			name:     "closing punctuation joins the previous word",
+			tokens:   []string{"ĠHello", ",", "Ġwor", "ld", "!"},
+			language: "en",
+			want:     [][]string{{"ĠHello", ","}, {"Ġwor", "ld", "!"}},
+		},
+		{
+			name:     "opening punctuation joins the next word",
+			tokens:   []string{"ĠHe", "Ġsaid", "Ġ(", "Ġwow", ")"},
+			language: "en",
+			want:     [][]string{{"ĠHe"}, {"Ġsaid"}, {"Ġ(", "Ġwow", ")"}},
+		},
+		{
+			name:     "character split across tokens in a spaced language",
+			tokens:   append([]string{"Ġx"}, japanese...),
+			language: "en",
+			want:     [][]string{append([]string{"Ġx"}, japanese...)},
+		},
+		{
+			name:     "every character is a word in an unspaced language",
+			tokens:   japanese,
+			language: "ja",
+			want:     [][]string{japanese[:3], japanese[3:]},
+		},
+		{
+			name:     "incomplete character at the end",
+			tokens:   japanese[:4],
+			language: "zh",
+			want:     [][]string{japanese[:3], japanese[3:4]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitWordTokens(tok, tt.tokens, tt.language); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitWordTokens(%q)\ngot  %q\nwant %q", tt.tokens, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlignmentWords(t *testing.T) {
+	tok := fixtureTokenizer()
+	ms := time.Millisecond
+	tokens := []string{"ĠHello", ",", "Ġwor", "ld"}
+
+	// The path reaches the text tokens at encoder positions 0, 25, 30 and
+	// 40, and the end-of-text token at 50; positions are 20ms apart
+	a := &Alignment{
+		TextIndices:    []int{0, 0, 1, 2, 2, 3, 4},
+		TimeIndices:    []int{0, 10, 25, 30, 35, 40, 50},
+		TextTokenProbs: []float32{0.9, 0.5, 0.6, 0.8},
+	}
+
+	words, counts := alignmentWords(tok, a, tokens, "en")
+
+	want := []Word{
+		{Start: 0, End: 600 * ms, Text: " Hello,", Probability: 0.7},
+		{Start: 600 * ms, End: 1000 * ms, Text: " world", Probability: 0.7},
+	}
+	if len(words) != len(want) {
+		t.Fatalf("alignmentWords = %+v, want %+v", words, want)
+	}
+	for i := range want {
+		got := words[i]
+		if got.Start != want[i].Start || got.End != want[i].End || got.Text != want[i].Text ||
+			math.Abs(float64(got.Probability-want[i].Probability)) > 1e-6 {
+			t.Errorf("word %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if !reflect.DeepEqual(counts, []int{2, 2}) {
+		t.Errorf("token counts = %v, want [2 2]", counts)
+	}
+}
+
+func TestAlignmentWordsShortPath(t *testing.T) {
+	tok := fixtureTokenizer()
+
+	// A path that stops before the last tokens leaves them at its last
+	// position
+	a := &Alignment{
+		TextIndices:    []int{0, 1},
+		TimeIndices:    []int{5, 15},
+		TextTokenProbs: []float32{0.5},
+	}
+	words, _ := alignmentWords(tok, a, []string{"Ġa", "Ġb", "Ġc"}, "en")
+
+	wantTimes := [][2]time.Duration{
+		{100 * time.Millisecond, 300 * time.Millisecond},
+		{300 * time.Millisecond, 300 * time.Millisecond},
+		{300 * time.Millisecond, 300 * time.Millisecond},
+	}
+	if len(words) != len(wantTimes) {
+		t.Fatalf("alignmentWords returned %d words, want %d", len(words), len(wantTimes))
+	}
+	for i, w := range words {
+		if w.Start != wantTimes[i][0] || w.End != wantTimes[i][1] {
+			t.Errorf("word %d spans %v-%v, want %v-%v", i, w.Start, w.End, wantTimes[i][0], wantTimes[i][1])
+		}
+	}
+	if words[0].Probability != 0.5 || words[1].Probability != 0 {
+		t.Errorf("probabilities = %v, %v, want 0.5 and 0 past the known ones", words[0].Probability, words[1].Probability)
+	}
+
+	if words, counts := alignmentWords(tok, &Alignment{}, []string{"Ġa"}, "en"); words != nil || counts != nil {
+		t.Errorf("alignmentWords of an empty path = %v, %v, want nil", words, counts)
+	}
+}
+
+func TestAssignWords(t *testing.T) {
+	s := time.Second
+	word := func(start, end time.Duration, text string) Word {
+		return Word{Start: start, End: end, Text: text}
+	}
+
+	tests := []struct {
+		name       string
+		counts     []int
+		words      []Word
+		wordCounts []int
+		want       [][]Word
+	}{
+		{
+			name:       "one token per word",
+			counts:     []int{2, 1},
+			words:      []Word{word(0, 1*s, "a"), word(1*s, 2*s, "b"), word(2*s, 3*s, "c")},
+			wordCounts: []int{1, 1, 1},
+			want: [][]Word{
+				{word(30*s, 31*s, "a"), word(31*s, 32*s, "b")},
+				{word(32*s, 33*s, "c")},
+			},
+		},
+		{
+			name:       "word spanning a segment boundary",
+			counts:     []int{2, 2},
+			words:      []Word{word(0, 1*s, "a"), word(1*s, 2*s, "bc"), word(2*s, 3*s, "d")},
+			wordCounts: []int{1, 2, 1},
+			want: [][]Word{
+				{word(30*s, 31*s, "a"), word(31*s, 32*s, "bc")},
+				{word(32*s, 33*s, "d")},
+			},
+		},
+		{
+			name:       "segment without text tokens",
+			counts:     []int{1, 0, 1},
+			words:      []Word{word(0, 1*s, "a"), word(1*s, 2*s, "b")},
+			wordCounts: []int{1, 1},
+			want: [][]Word{
+				{word(30*s, 31*s, "a")},
+				nil,
+				{word(31*s, 32*s, "b")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments := make([]Segment, len(tt.counts))
+			assignWords(segments, tt.counts, tt.words, tt.wordCounts, 30*s)
+
+			for i, seg := range segments {
+				if !reflect.DeepEqual(seg.Words, tt.want[i]) {
+					t.Errorf("segment %d words = %+v, want %+v", i, seg.Words, tt.want[i])
+				}
+			}
+		})
+	}
+}
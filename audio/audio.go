@@ -0,0 +1,62 @@
+// Package audio decodes audio files into float32 PCM samples ready for
+// Whisper feature extraction.
+package audio
+
+import (
+	"fmt"
+	"time"
+)
+
+// PCM holds decoded audio as planar float32 samples normalized to [-1, 1],
+// one slice per channel.
+type PCM struct {
+	SampleRate int
+	Channels   [][]float32
+}
+
+// Len returns the number of samples per channel.
+func (p *PCM) Len() int {
+	if len(p.Channels) == 0 {
+		return 0
+	}
+
+	return len(p.Channels[0])
+}
+
+// Duration returns the length of the audio.
+func (p *PCM) Duration() time.Duration {
+	if p.SampleRate == 0 {
+		return 0
+	}
+
+	return time.Duration(p.Len()) * time.Second / time.Duration(p.SampleRate)
+}
+
+// Mono returns the channels mixed down to a single channel.
+func (p *PCM) Mono() []float32 {
+	switch len(p.Channels) {
+	case 0:
+		return nil
+	case 1:
+		return p.Channels[0]
+	}
+
+	mono := make([]float32, p.Len())
+	scale := 1 / float32(len(p.Channels))
+	for _, ch := range p.Channels {
+		for i, s := range ch {
+			mono[i] += s * scale
+		}
+	}
+
+	return mono
+}
+
+// Channel returns the samples of channel n, counting from zero.
+func (p *PCM) Channel(n int) ([]float32, error) {
+	if n < 0 || n >= len(p.Channels) {
+		return nil, fmt.Errorf("audio: channel %d out of range, audio has %d channels", n, len(p.Channels))
+	}
+
+	return p.Channels[n], nil
+}
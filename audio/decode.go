@@ -0,0 +1,84 @@
+package audio
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrFormat indicates that the audio format was not recognized.
+var ErrFormat = errors.New("audio: unknown format")
+
+// DecodeFunc decodes a complete audio stream.
+type DecodeFunc func(r io.Reader) (*PCM, error)
+
+// format is a registered audio format.
+type format struct {
+	name   string
+	magic  string
+	decode DecodeFunc
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   []format
+)
+
+// Register registers an audio format for use by Decode. name is the name of
+// the format, like "wav" or "mp3". magic is the signature identifying the
+// format at the start of the stream; each "?" in magic matches any byte.
+// Formats are tried in registration order, so a format can be registered
+// several times with different signatures.
+func Register(name, magic string, decode DecodeFunc) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	formats = append(formats, format{name: name, magic: magic, decode: decode})
+}
+
+// Decode decodes an audio stream in a registered format, detected from the
+// signature at the start of the stream. The string returned is the format
+// name used during format registration. WAV, MP3, FLAC and Ogg Vorbis are
+// registered by default.
+func Decode(r io.Reader) (*PCM, string, error) {
+	br := bufio.NewReader(r)
+
+	f, ok := sniff(br)
+	if !ok {
+		return nil, "", ErrFormat
+	}
+
+	pcm, err := f.decode(br)
+	return pcm, f.name, err
+}
+
+// sniff returns the first format whose signature matches the start of r.
+func sniff(r *bufio.Reader) (format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for _, f := range formats {
+		b, err := r.Peek(len(f.magic))
+		if err == nil && match(f.magic, b) {
+			return f, true
+		}
+	}
+
+	return format{}, false
+}
+
+// match reports whether magic matches b, treating "?" as a wildcard.
+func match(magic string, b []byte) bool {
+	if len(magic) != len(b) {
+		return false
+	}
+
+	for i, c := range b {
+		if magic[i] != c && magic[i] != '?' {
+			return false
+		}
+	}
+
+	return true
+}
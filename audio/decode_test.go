@@ -0,0 +1,110 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		magic string
+		b     string
+		want  bool
+	}{
+		{magic: "fLaC", b: "fLaC", want: true},
+		{magic: "fLaC", b: "fLaX", want: false},
+		{magic: "RIFF????WAVE", b: "RIFF\x24\x08\x00\x00WAVE", want: true},
+		{magic: "RIFF????WAVE", b: "RIFF\x24\x08\x00\x00AVI ", want: false},
+		{magic: "a?c", b: "a?c", want: true},
+		{magic: "abc", b: "ab", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := match(tt.magic, []byte(tt.b)); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.magic, tt.b, got, tt.want)
+		}
+	}
+}
+
+// oggPage returns the start of the first page of an Ogg stream whose single
+// segment begins with packet.
+func oggPage(packet string) []byte {
+	b := []byte("OggS")
+	b = append(b, 0, 0x02)                     // version, beginning of stream
+	b = binary.LittleEndian.AppendUint64(b, 0) // granule position
+	b = binary.LittleEndian.AppendUint32(b, 0x1234)
+	b = binary.LittleEndian.AppendUint32(b, 0) // page sequence number
+	b = binary.LittleEndian.AppendUint32(b, 0) // checksum
+	b = append(b, 1, 30)                       // one segment of 30 bytes
+
+	return append(b, packet...)
+}
+
+func TestSniffDefaultFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{name: "wav", header: []byte("RIFF\x24\x08\x00\x00WAVEfmt "), want: "wav"},
+		{name: "mp3 with ID3 tag", header: []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), want: "mp3"},
+		{name: "mp3 frame", header: []byte("\xff\xfb\x90\x64\x00\x00"), want: "mp3"},
+		{name: "mpeg-2 mp3 frame", header: []byte("\xff\xf3\x64\xc4\x00\x00"), want: "mp3"},
+		{name: "flac", header: []byte("fLaC\x00\x00\x00\x22"), want: "flac"},
+		{name: "ogg vorbis", header: oggPage("\x01vorbis\x00\x00\x00\x00"), want: "vorbis"},
+		{name: "ogg opus", header: oggPage("OpusHead\x01\x02"), want: ""},
+		{name: "riff avi", header: []byte("RIFF\x24\x08\x00\x00AVI LIST"), want: ""},
+		{name: "too short", header: []byte("fLa"), want: ""},
+		{name: "empty", header: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := sniff(bufio.NewReader(bytes.NewReader(tt.header)))
+			if got := f.name; got != tt.want || ok != (tt.want != "") {
+				t.Errorf("sniff = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeUnknownFormat(t *testing.T) {
+	_, _, err := Decode(strings.NewReader("not an audio file"))
+	if !errors.Is(err, ErrFormat) {
+		t.Errorf("Decode error = %v, want ErrFormat", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	// decodeAs returns a decoder that checks it is handed the whole stream
+	decodeAs := func(rate int) DecodeFunc {
+		return func(r io.Reader) (*PCM, error) {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(string(data), "XTST") {
+				return nil, errors.New("stream does not start with the signature")
+			}
+			return &PCM{SampleRate: rate, Channels: [][]float32{{0}}}, nil
+		}
+	}
+
+	// Formats are tried in registration order, so the wildcard signature
+	// registered first wins over the exact one
+	Register("xtst-any", "XTS?", decodeAs(1))
+	Register("xtst", "XTST", decodeAs(2))
+
+	pcm, name, err := Decode(strings.NewReader("XTST payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "xtst-any" || pcm.SampleRate != 1 {
+		t.Errorf("Decode used %q at %d Hz, want xtst-any at 1 Hz", name, pcm.SampleRate)
+	}
+}
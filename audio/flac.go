@@ -0,0 +1,61 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mewkiz/flac"
+)
+
+func init() {
+	Register("flac", "fLaC", DecodeFLAC)
+}
+
+// maxFLACPrealloc caps the samples allocated per channel up front. The
+// sample count in the header comes from the file, so it cannot be trusted
+// for a large allocation; longer streams grow as frames are decoded.
+const maxFLACPrealloc = 1 << 22
+
+// DecodeFLAC decodes a FLAC stream.
+func DecodeFLAC(r io.Reader) (*PCM, error) {
+	stream, err := flac.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("flac: %w", err)
+	}
+
+	info := stream.Info
+	if info.NChannels < 1 {
+		return nil, errors.New("flac: no channels")
+	}
+
+	scale := 1 / float32(int64(1)<<(info.BitsPerSample-1))
+	channels := make([][]float32, info.NChannels)
+	for c := range channels {
+		channels[c] = make([]float32, 0, min(info.NSamples, maxFLACPrealloc))
+	}
+
+	for {
+		frame, err := stream.ParseNext()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("flac: %w", err)
+		}
+
+		if len(frame.Subframes) > len(channels) {
+			return nil, fmt.Errorf("flac: frame has %d channels, stream has %d", len(frame.Subframes), len(channels))
+		}
+		for c, sub := range frame.Subframes {
+			for _, s := range sub.Samples[:sub.NSamples] {
+				channels[c] = append(channels[c], float32(s)*scale)
+			}
+		}
+	}
+
+	return &PCM{
+		SampleRate: int(info.SampleRate),
+		Channels:   channels,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// flacHeader returns the signature and STREAMINFO block of a FLAC stream
+// with no frames.
+func flacHeader(rate, channels, bits int, samples uint64) []byte {
+	b := []byte("fLaC")
+	b = append(b, 0x80, 0, 0, 34) // last metadata block, STREAMINFO, 34 bytes
+
+	b = binary.BigEndian.AppendUint16(b, 4096) // min block size
+	b = binary.BigEndian.AppendUint16(b, 4096) // max block size
+	b = append(b, 0, 0, 0, 0, 0, 0)            // min and max frame size
+
+	// 20 bits of sample rate, 3 of channels-1, 5 of bits-1 and 36 of
+	// sample count
+	v := uint64(rate)<<44 | uint64(channels-1)<<41 | uint64(bits-1)<<36 | samples&(1<<36-1)
+	b = binary.BigEndian.AppendUint64(b, v)
+
+	return append(b, make([]byte, 16)...) // MD5 signature
+}
+
+func TestDecodeFLACHugeSampleCount(t *testing.T) {
+	// The header claims 2^36-1 samples per channel, about 256 GiB of
+	// float32 each, but the stream holds none
+	pcm, format, err := Decode(bytes.NewReader(flacHeader(44100, 8, 16, 1<<36-1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if format != "flac" {
+		t.Errorf("format = %q, want flac", format)
+	}
+	if len(pcm.Channels) != 8 || pcm.Len() != 0 || pcm.SampleRate != 44100 {
+		t.Errorf("decoded %d channels of %d samples at %d Hz, want 8 empty channels at 44100 Hz", len(pcm.Channels), pcm.Len(), pcm.SampleRate)
+	}
+	for c, ch := range pcm.Channels {
+		if cap(ch) > maxFLACPrealloc {
+			t.Errorf("channel %d preallocated %d samples, want at most %d", c, cap(ch), maxFLACPrealloc)
+		}
+	}
+}
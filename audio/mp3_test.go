@@ -0,0 +1,29 @@
+package audio
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDecodeMP3Mono(t *testing.T) {
+	f, err := os.Open("../testdata/tts-sample.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	pcm, format, err := Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if format != "mp3" {
+		t.Errorf("format = %q, want mp3", format)
+	}
+	if len(pcm.Channels) != 1 {
+		t.Errorf("mono MP3 decoded to %d channels, want 1", len(pcm.Channels))
+	}
+	if pcm.SampleRate != 24000 || pcm.Len() == 0 {
+		t.Errorf("decoded %d samples at %d Hz", pcm.Len(), pcm.SampleRate)
+	}
+}
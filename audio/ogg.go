@@ -0,0 +1,43 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+func init() {
+	// The first Ogg page holds a single segment with the Vorbis
+	// identification header, which follows the 27-byte page header and its
+	// one-byte segment table
+	Register("vorbis", "OggS????????????????????????\x01vorbis", DecodeVorbis)
+}
+
+// DecodeVorbis decodes an Ogg Vorbis stream.
+func DecodeVorbis(r io.Reader) (*PCM, error) {
+	data, format, err := oggvorbis.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("vorbis: %w", err)
+	}
+
+	if format.Channels < 1 {
+		return nil, errors.New("vorbis: no channels")
+	}
+
+	// Split the interleaved samples into channels
+	n := len(data) / format.Channels
+	channels := make([][]float32, format.Channels)
+	for c := range channels {
+		channels[c] = make([]float32, n)
+		for i := 0; i < n; i++ {
+			channels[c][i] = data[i*format.Channels+c]
+		}
+	}
+
+	return &PCM{
+		SampleRate: format.SampleRate,
+		Channels:   channels,
+	}, nil
+}
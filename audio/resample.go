@@ -0,0 +1,186 @@
+package audio
+
+import (
+	"errors"
+	"math"
+)
+
+// Resampler filter design. A larger zeroCrossings gives a sharper cutoff at
+// the cost of more taps per output sample; kaiserBeta trades stopband
+// attenuation (about 90dB here) against transition width.
+const (
+	zeroCrossings = 16
+	rolloff       = 0.94
+	kaiserBeta    = 8.6
+)
+
+// Resampler converts a stream of samples from one sample rate to another
+// with a band-limited Kaiser-windowed sinc filter. When downsampling, the
+// cutoff sits just below the output Nyquist frequency so content above it
+// is removed instead of aliasing. Input can be fed in chunks of any size;
+// the output is the same as resampling the concatenated input at once.
+type Resampler struct {
+	up, down int         // rate ratio reduced to lowest terms
+	half     int         // taps on each side of the filter center
+	phases   [][]float32 // filter taps for each of the up output phases
+
+	buf      []float32 // input samples not yet fully consumed
+	bufStart int64     // absolute index of buf[0]
+	consumed int64     // number of input samples received
+	next     int64     // absolute index of the next output sample
+}
+
+// NewResampler returns a resampler from the from sample rate to the to
+// sample rate.
+func NewResampler(from, to int) (*Resampler, error) {
+	if from <= 0 || to <= 0 {
+		return nil, errors.New("audio: sample rates must be positive")
+	}
+
+	g := gcd(from, to)
+	r := Resampler{
+		up:   to / g,
+		down: from / g,
+	}
+
+	// Cutoff relative to the input Nyquist frequency
+	cutoff := rolloff * min(1, float64(r.up)/float64(r.down))
+	r.half = int(math.Ceil(zeroCrossings / cutoff))
+
+	// Tap k of phase p weighs the input sample at distance p/up + half-1-k
+	// from the output position
+	r.phases = make([][]float32, r.up)
+	for p := range r.phases {
+		vals := make([]float64, 2*r.half)
+		var sum float64
+		for k := range vals {
+			x := float64(p)/float64(r.up) + float64(r.half-1-k)
+			vals[k] = cutoff * sinc(cutoff*x) * kaiser(x/float64(r.half))
+			sum += vals[k]
+		}
+
+		// Normalize every phase to unity gain at DC
+		taps := make([]float32, len(vals))
+		for k, v := range vals {
+			taps[k] = float32(v / sum)
+		}
+		r.phases[p] = taps
+	}
+
+	return &r, nil
+}
+
+// Process resamples the next chunk of input and returns the output samples
+// that can be computed so far. The remaining output is returned by Flush.
+func (r *Resampler) Process(in []float32) []float32 {
+	r.buf = append(r.buf, in...)
+	r.consumed += int64(len(in))
+
+	return r.drain(r.consumed)
+}
+
+// Flush returns the output samples that depend on input past the end of the
+// stream, which is treated as silence, and resets the resampler.
+func (r *Resampler) Flush() []float32 {
+	// Every output sample positioned before the end of the input
+	out := r.drain(r.consumed + int64(r.half))
+
+	*r = Resampler{up: r.up, down: r.down, half: r.half, phases: r.phases}
+	return out
+}
+
+// drain computes every output sample whose filter window ends before the
+// input sample at index limit. Input samples before the start of the stream
+// and past r.consumed are treated as silence.
+func (r *Resampler) drain(limit int64) []float32 {
+	up, down := int64(r.up), int64(r.down)
+	half := int64(r.half)
+
+	var out []float32
+	for {
+		t := r.next * down
+		center := t / up
+		if center+half >= limit {
+			break
+		}
+
+		taps := r.phases[t%up]
+		first := center - half + 1
+
+		var acc float32
+		for k, h := range taps {
+			j := first + int64(k)
+			if j < r.bufStart || j >= r.consumed {
+				continue
+			}
+			acc += h * r.buf[j-r.bufStart]
+		}
+		out = append(out, acc)
+		r.next++
+	}
+
+	// Drop the input no longer needed by the next output sample
+	keep := (r.next*down)/up - half + 1
+	if drop := keep - r.bufStart; drop > 0 {
+		drop = min(drop, int64(len(r.buf)))
+		r.buf = r.buf[drop:]
+		r.bufStart += drop
+	}
+
+	return out
+}
+
+// Resample converts samples from the from sample rate to the to sample
+// rate with a band-limited filter.
+func Resample(samples []float32, from, to int) ([]float32, error) {
+	if from == to {
+		return samples, nil
+	}
+
+	r, err := NewResampler(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(r.Process(samples), r.Flush()...), nil
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// kaiser evaluates a Kaiser window at x in [-1, 1].
+func kaiser(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+
+	return besselI0(kaiserBeta*math.Sqrt(1-x*x)) / besselI0(kaiserBeta)
+}
+
+// besselI0 computes the zeroth-order modified Bessel function of the first
+// kind from its power series.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 50; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+
+	return sum
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
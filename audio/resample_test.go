@@ -0,0 +1,114 @@
+package audio
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// tone returns seconds of a sine wave of amplitude 1 at freq Hz.
+func tone(freq float64, rate int, seconds float64) []float32 {
+	samples := make([]float32, int(seconds*float64(rate)))
+	for i := range samples {
+		samples[i] = float32(math.Sin(2 * math.Pi * freq * float64(i) / float64(rate)))
+	}
+
+	return samples
+}
+
+// levelDB returns the RMS level of samples relative to a full-scale sine,
+// ignoring a tenth of the signal at each end where the filter ramps up.
+func levelDB(samples []float32) float64 {
+	edge := len(samples) / 10
+	samples = samples[edge : len(samples)-edge]
+
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	rms := math.Sqrt(sum / float64(len(samples)))
+
+	return 20 * math.Log10(rms*math.Sqrt2)
+}
+
+func TestResampleLevels(t *testing.T) {
+	tests := []struct {
+		from int
+		freq float64
+		min  float64 // dB
+		max  float64 // dB
+	}{
+		// Passband
+		{from: 44100, freq: 1000, min: -0.05, max: 0.05},
+		{from: 48000, freq: 1000, min: -0.05, max: 0.05},
+		{from: 44100, freq: 6000, min: -0.05, max: 0.05},
+		{from: 48000, freq: 6000, min: -0.05, max: 0.05},
+
+		// Stopband: these tones would alias to 7.1kHz, 4kHz and 1kHz
+		{from: 44100, freq: 8900, max: -80},
+		{from: 48000, freq: 12000, max: -80},
+		{from: 48000, freq: 17000, max: -80},
+	}
+
+	for _, tt := range tests {
+		out, err := Resample(tone(tt.freq, tt.from, 2), tt.from, 16000)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := 2 * 16000; len(out) != want {
+			t.Errorf("%d Hz: got %d samples, want %d", tt.from, len(out), want)
+		}
+
+		level := levelDB(out)
+		if level > tt.max || (tt.min != 0 && level < tt.min) {
+			t.Errorf("%d Hz, %g Hz tone: output level %.2f dB, want within [%g, %g]", tt.from, tt.freq, level, tt.min, tt.max)
+		}
+	}
+}
+
+func TestResamplerChunks(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	samples := make([]float32, 44100)
+	for i := range samples {
+		samples[i] = rng.Float32()*2 - 1
+	}
+
+	for _, from := range []int{8000, 22050, 44100, 48000} {
+		want, err := Resample(samples, from, 16000)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := NewResampler(from, 16000)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []float32
+		for in := samples; len(in) > 0; {
+			n := min(len(in), rng.IntN(1000))
+			got = append(got, r.Process(in[:n])...)
+			in = in[n:]
+		}
+		got = append(got, r.Flush()...)
+
+		if len(got) != len(want) {
+			t.Fatalf("%d Hz: chunked output has %d samples, want %d", from, len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%d Hz: chunked sample %d = %v, want %v", from, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestResampleErrors(t *testing.T) {
+	if _, err := NewResampler(0, 16000); err == nil {
+		t.Error("NewResampler with a zero rate succeeded")
+	}
+	if _, err := Resample([]float32{1}, 16000, -1); err == nil {
+		t.Error("Resample with a negative rate succeeded")
+	}
+}
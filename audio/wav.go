@@ -0,0 +1,198 @@
+package audio
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+func init() {
+	Register("wav", "RIFF????WAVE", DecodeWAV)
+}
+
+// WAV format tags.
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatIEEEFloat  = 0x0003
+	wavFormatExtensible = 0xFFFE
+)
+
+// maxWAVFormatSize is the size of the largest "fmt " chunk, that of
+// WAVE_FORMAT_EXTENSIBLE files, rounded up.
+const maxWAVFormatSize = 64
+
+// wavFormat is the content of the "fmt " chunk.
+type wavFormat struct {
+	tag           uint16
+	channels      int
+	sampleRate    int
+	blockAlign    int
+	bitsPerSample int
+}
+
+// DecodeWAV decodes a WAV stream holding integer PCM with 8, 16, 24 or 32
+// bits per sample, or IEEE float samples with 32 or 64 bits, including
+// WAVE_FORMAT_EXTENSIBLE files. Every channel is returned separately.
+func DecodeWAV(r io.Reader) (*PCM, error) {
+	br := bufio.NewReader(r)
+
+	var header [12]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return nil, fmt.Errorf("wav: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("wav: invalid WAV file")
+	}
+
+	var format *wavFormat
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(br, chunk[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, errors.New("wav: missing data chunk")
+			}
+			return nil, fmt.Errorf("wav: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			// Only the start of the chunk is meaningful, so a bogus size
+			// cannot cause a large allocation
+			data := make([]byte, min(size, maxWAVFormatSize))
+			if _, err := io.ReadFull(br, data); err != nil {
+				return nil, fmt.Errorf("wav: fmt chunk: %w", err)
+			}
+			if _, err := br.Discard(int(size - int64(len(data)))); err != nil {
+				return nil, fmt.Errorf("wav: fmt chunk: %w", err)
+			}
+			f, err := parseWAVFormat(data)
+			if err != nil {
+				return nil, err
+			}
+			format = f
+
+		case "data":
+			if format == nil {
+				return nil, errors.New("wav: data chunk before fmt chunk")
+			}
+
+			// Streamed files may leave the size unset, so read what is there.
+			// Truncated files are read up to their end.
+			var src io.Reader = br
+			if size != 0 && size != math.MaxUint32 {
+				src = io.LimitReader(br, size)
+			}
+			data, err := io.ReadAll(src)
+			if err != nil {
+				return nil, fmt.Errorf("wav: data chunk: %w", err)
+			}
+
+			return decodeWAVSamples(format, data)
+
+		default:
+			if _, err := br.Discard(int(size)); err != nil {
+				return nil, fmt.Errorf("wav: %s chunk: %w", id, err)
+			}
+		}
+
+		// Chunks are padded to an even size
+		if size%2 == 1 {
+			if _, err := br.Discard(1); err != nil && err != io.EOF {
+				return nil, fmt.Errorf("wav: %w", err)
+			}
+		}
+	}
+}
+
+func parseWAVFormat(data []byte) (*wavFormat, error) {
+	if len(data) < 16 {
+		return nil, errors.New("wav: fmt chunk too short")
+	}
+
+	f := wavFormat{
+		tag:           binary.LittleEndian.Uint16(data[0:2]),
+		channels:      int(binary.LittleEndian.Uint16(data[2:4])),
+		sampleRate:    int(binary.LittleEndian.Uint32(data[4:8])),
+		blockAlign:    int(binary.LittleEndian.Uint16(data[12:14])),
+		bitsPerSample: int(binary.LittleEndian.Uint16(data[14:16])),
+	}
+
+	// The real format of extensible files is in the first two bytes of
+	// the sub-format GUID
+	if f.tag == wavFormatExtensible {
+		if len(data) < 26 {
+			return nil, errors.New("wav: extensible fmt chunk too short")
+		}
+		f.tag = binary.LittleEndian.Uint16(data[24:26])
+	}
+
+	if f.channels < 1 {
+		return nil, errors.New("wav: no channels")
+	}
+	if f.sampleRate < 1 {
+		return nil, errors.New("wav: invalid sample rate")
+	}
+
+	switch {
+	case f.tag == wavFormatPCM && (f.bitsPerSample == 8 || f.bitsPerSample == 16 || f.bitsPerSample == 24 || f.bitsPerSample == 32):
+	case f.tag == wavFormatIEEEFloat && (f.bitsPerSample == 32 || f.bitsPerSample == 64):
+	default:
+		return nil, fmt.Errorf("wav: unsupported format %#04x with %d bits per sample", f.tag, f.bitsPerSample)
+	}
+
+	// Samples are stored in whole bytes even when the block align says
+	// otherwise
+	if minAlign := f.channels * f.bitsPerSample / 8; f.blockAlign < minAlign {
+		f.blockAlign = minAlign
+	}
+
+	return &f, nil
+}
+
+// decodeWAVSamples splits interleaved sample frames into channels and scales
+// them to [-1, 1].
+func decodeWAVSamples(f *wavFormat, data []byte) (*PCM, error) {
+	bytesPerSample := f.bitsPerSample / 8
+	n := len(data) / f.blockAlign
+
+	channels := make([][]float32, f.channels)
+	for c := range channels {
+		channels[c] = make([]float32, n)
+	}
+
+	for i := 0; i < n; i++ {
+		frame := data[i*f.blockAlign:]
+		for c := range channels {
+			b := frame[c*bytesPerSample:]
+
+			var v float32
+			switch {
+			case f.tag == wavFormatIEEEFloat && bytesPerSample == 4:
+				v = math.Float32frombits(binary.LittleEndian.Uint32(b))
+			case f.tag == wavFormatIEEEFloat:
+				v = float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+			case bytesPerSample == 1:
+				// 8-bit samples are unsigned
+				v = (float32(b[0]) - 128) / 128
+			case bytesPerSample == 2:
+				v = float32(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+			case bytesPerSample == 3:
+				s := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+				v = float32(s) / (1 << 23)
+			default:
+				v = float32(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+			}
+			channels[c][i] = v
+		}
+	}
+
+	return &PCM{
+		SampleRate: f.sampleRate,
+		Channels:   channels,
+	}, nil
+}
@@ -0,0 +1,196 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// chunk returns a RIFF chunk, padded to an even size.
+func chunk(id string, size uint32, data []byte) []byte {
+	b := binary.LittleEndian.AppendUint32([]byte(id), size)
+	b = append(b, data...)
+	if len(data)%2 == 1 {
+		b = append(b, 0)
+	}
+
+	return b
+}
+
+// fmtChunk returns the content of a "fmt " chunk, in the extensible layout
+// when extensible is set.
+func fmtChunk(tag uint16, channels, rate, bits int, extensible bool) []byte {
+	align := channels * bits / 8
+
+	var b []byte
+	if extensible {
+		b = binary.LittleEndian.AppendUint16(b, wavFormatExtensible)
+	} else {
+		b = binary.LittleEndian.AppendUint16(b, tag)
+	}
+	b = binary.LittleEndian.AppendUint16(b, uint16(channels))
+	b = binary.LittleEndian.AppendUint32(b, uint32(rate))
+	b = binary.LittleEndian.AppendUint32(b, uint32(rate*align))
+	b = binary.LittleEndian.AppendUint16(b, uint16(align))
+	b = binary.LittleEndian.AppendUint16(b, uint16(bits))
+
+	if extensible {
+		b = binary.LittleEndian.AppendUint16(b, 22) // extension size
+		b = binary.LittleEndian.AppendUint16(b, uint16(bits))
+		b = binary.LittleEndian.AppendUint32(b, 0) // channel mask
+		b = binary.LittleEndian.AppendUint16(b, tag)
+		b = append(b, "\x00\x00\x00\x00\x10\x00\x80\x00\x00\xaa\x00\x38\x9b\x71"...)
+	}
+
+	return b
+}
+
+// riff wraps chunks into a WAV file.
+func riff(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+
+	return append(binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+}
+
+// wavFile returns a WAV file with a fmt chunk and a data chunk.
+func wavFile(tag uint16, channels, bits int, data []byte) []byte {
+	f := fmtChunk(tag, channels, 16000, bits, false)
+	return riff(chunk("fmt ", uint32(len(f)), f), chunk("data", uint32(len(data)), data))
+}
+
+func le16(vs ...int16) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = binary.LittleEndian.AppendUint16(b, uint16(v))
+	}
+	return b
+}
+
+func le32(vs ...uint32) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = binary.LittleEndian.AppendUint32(b, v)
+	}
+	return b
+}
+
+func le64(vs ...uint64) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = binary.LittleEndian.AppendUint64(b, v)
+	}
+	return b
+}
+
+func TestDecodeWAV(t *testing.T) {
+	int24 := []byte{0x00, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x40}
+	int32s := le32(1<<31, 0, 1<<30)
+	float32s := le32(math.Float32bits(-1), math.Float32bits(0), math.Float32bits(0.5))
+	float64s := le64(math.Float64bits(-1), math.Float64bits(0), math.Float64bits(0.5))
+	want := [][]float32{{-1, 0, 0.5}}
+
+	pcm16 := fmtChunk(wavFormatPCM, 1, 16000, 16, false)
+	stereo := fmtChunk(wavFormatPCM, 2, 16000, 16, true)
+	floatExt := fmtChunk(wavFormatIEEEFloat, 1, 16000, 32, true)
+
+	tests := []struct {
+		name string
+		file []byte
+		want [][]float32
+	}{
+		{name: "8-bit PCM", file: wavFile(wavFormatPCM, 1, 8, []byte{0, 128, 192}), want: want},
+		{name: "16-bit PCM", file: wavFile(wavFormatPCM, 1, 16, le16(-32768, 0, 16384)), want: want},
+		{name: "24-bit PCM", file: wavFile(wavFormatPCM, 1, 24, int24), want: want},
+		{name: "32-bit PCM", file: wavFile(wavFormatPCM, 1, 32, int32s), want: want},
+		{name: "32-bit float", file: wavFile(wavFormatIEEEFloat, 1, 32, float32s), want: want},
+		{name: "64-bit float", file: wavFile(wavFormatIEEEFloat, 1, 64, float64s), want: want},
+		{
+			name: "extensible stereo PCM",
+			file: riff(chunk("fmt ", uint32(len(stereo)), stereo), chunk("data", 8, le16(-32768, 16384, 0, -16384))),
+			want: [][]float32{{-1, 0}, {0.5, -0.5}},
+		},
+		{
+			name: "extensible float",
+			file: riff(chunk("fmt ", uint32(len(floatExt)), floatExt), chunk("data", 12, float32s)),
+			want: want,
+		},
+		{
+			name: "odd-sized chunk before fmt",
+			file: riff(chunk("LIST", 3, []byte("abc")), chunk("fmt ", 16, pcm16), chunk("data", 6, le16(-32768, 0, 16384))),
+			want: want,
+		},
+		{
+			name: "unset data size",
+			file: riff(chunk("fmt ", 16, pcm16), chunk("data", 0, le16(-32768, 0, 16384))),
+			want: want,
+		},
+		{
+			name: "streamed data size",
+			file: riff(chunk("fmt ", 16, pcm16), chunk("data", math.MaxUint32, le16(-32768, 0, 16384))),
+			want: want,
+		},
+		{
+			name: "truncated data chunk",
+			file: riff(chunk("fmt ", 16, pcm16), chunk("data", 1000, le16(-32768, 0, 16384))),
+			want: want,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pcm, format, err := Decode(bytes.NewReader(tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != "wav" {
+				t.Errorf("format = %q, want wav", format)
+			}
+			if pcm.SampleRate != 16000 {
+				t.Errorf("sample rate = %d, want 16000", pcm.SampleRate)
+			}
+
+			if len(pcm.Channels) != len(tt.want) {
+				t.Fatalf("got %d channels, want %d", len(pcm.Channels), len(tt.want))
+			}
+			for c := range tt.want {
+				if len(pcm.Channels[c]) != len(tt.want[c]) {
+					t.Fatalf("channel %d = %v, want %v", c, pcm.Channels[c], tt.want[c])
+				}
+				for i := range tt.want[c] {
+					if pcm.Channels[c][i] != tt.want[c][i] {
+						t.Fatalf("channel %d = %v, want %v", c, pcm.Channels[c], tt.want[c])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeWAVErrors(t *testing.T) {
+	pcm16 := fmtChunk(wavFormatPCM, 1, 16000, 16, false)
+
+	tests := []struct {
+		name string
+		file []byte
+	}{
+		{name: "not RIFF", file: []byte("RIFX\x00\x00\x00\x00WAVE")},
+		{name: "no data chunk", file: riff(chunk("fmt ", 16, pcm16))},
+		{name: "data before fmt", file: riff(chunk("data", 2, le16(0)), chunk("fmt ", 16, pcm16))},
+		{name: "short fmt chunk", file: riff(chunk("fmt ", 8, pcm16[:8]), chunk("data", 2, le16(0)))},
+		{name: "unsupported bits", file: wavFile(wavFormatPCM, 1, 12, le16(0))},
+		{name: "unsupported format", file: wavFile(0x0055, 1, 16, le16(0))},
+		{name: "huge fmt chunk", file: riff(chunk("fmt ", math.MaxUint32-1, pcm16))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeWAV(bytes.NewReader(tt.file)); err == nil {
+				t.Error("DecodeWAV succeeded")
+			}
+		})
+	}
+}
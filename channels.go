@@ -0,0 +1,60 @@
+package ctranslate2ffi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// TranscribeChannels transcribes every channel of a multi-channel recording
+// separately, such as a call recording with the agent and the customer on
+// different channels, and merges the segments into a single timeline ordered
+// by start time. mels holds the log-mel spectrogram of each channel in the
+// layout Transcribe expects. Every segment is tagged with its channel and,
+// when speakers has an entry for that channel, with the speaker name.
+//
+// Speech regions can be given per channel with opts.ChannelSpeech.
+//
+// When opts.Language is empty, the language is detected from the first
+// channel and used for the others so the channels are decoded consistently.
+func (w *Whisper) TranscribeChannels(mels [][]float32, speakers []string, opts TranscribeOptions) (*Transcript, error) {
+	if len(mels) == 0 {
+		return nil, errors.New("no channels to transcribe")
+	}
+
+	merged := &Transcript{Language: opts.Language}
+	for ch, mel := range mels {
+		opts.Language = merged.Language
+		if opts.ChannelSpeech != nil {
+			opts.Speech = nil
+			if ch < len(opts.ChannelSpeech) {
+				opts.Speech = opts.ChannelSpeech[ch]
+			}
+		}
+
+		tr, err := w.Transcribe(mel, opts)
+		if err != nil {
+			return nil, fmt.Errorf("channel %d: %w", ch, err)
+		}
+		merged.Language = tr.Language
+
+		var speaker string
+		if ch < len(speakers) {
+			speaker = speakers[ch]
+		}
+
+		for _, seg := range tr.Segments {
+			seg.Channel = ch
+			seg.Speaker = speaker
+			merged.Segments = append(merged.Segments, seg)
+		}
+	}
+
+	// Segments of each channel are already in order, so a stable sort keeps
+	// the lower channel first when two segments start together
+	sort.SliceStable(merged.Segments, func(i, j int) bool {
+		return merged.Segments[i].Start < merged.Segments[j].Start
+	})
+
+	return merged, nil
+}
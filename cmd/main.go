@@ -13,20 +13,20 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"math"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/ardanlabs/ctranslate2ffi"
-
-	"github.com/go-audio/audio"
-	"github.com/go-audio/wav"
+	"github.com/ardanlabs/ctranslate2ffi/audio"
+	"github.com/ardanlabs/ctranslate2ffi/mel"
+	"github.com/ardanlabs/ctranslate2ffi/subtitle"
+	"github.com/ardanlabs/ctranslate2ffi/vad"
 )
 
 func main() {
@@ -34,13 +34,43 @@ func main() {
 	libPath := flag.String("lib", "/usr/local/lib", "Path to CTranslate2 library directory")
 	modelPath := flag.String("model", "", "Path to Whisper CTranslate2 model directory")
 	audioFile := flag.String("audio", "tts-sample.mp3", "Audio file to transcribe")
-	language := flag.String("lang", "en", "Language code (e.g., en, es, fr)")
+	language := flag.String("lang", "", "Language code or name (e.g., en, es, French); detected from the audio when empty")
+	channel := flag.Int("channel", -1, "Audio channel to transcribe, counting from 0; -1 mixes all channels. Cannot be combined with -split-channels")
+	splitChannels := flag.Bool("split-channels", false, "Transcribe every channel separately and merge the segments")
+	format := flag.String("format", "text", "Output format: text, srt, vtt, tsv or json")
+	output := flag.String("output", "", "Output file for -format other than text; defaults to the audio file name with the format's extension")
+	initialPrompt := flag.String("prompt", "", "Initial prompt to condition the transcription, such as a glossary of names")
+	wordTimestamps := flag.Bool("word-timestamps", false, "Time every word of the transcription")
+	useVAD := flag.Bool("vad", false, "Skip silence by detecting speech before transcribing")
+	speakers := flag.String("speakers", "", "Comma-separated speaker names for the channels with -split-channels (e.g., agent,customer)")
 	flag.Parse()
 
 	if *modelPath == "" {
 		log.Fatal("Please provide the path to a Whisper model with -model flag")
 	}
 
+	if *splitChannels && *channel >= 0 {
+		log.Fatal("-channel selects a single channel and cannot be combined with -split-channels")
+	}
+
+	var writer subtitle.Writer
+	if *format != "text" {
+		var err error
+		writer, err = subtitle.ForFormat(*format)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var langCode string
+	if *language != "" {
+		lang, ok := ctranslate2ffi.LookupLanguage(*language)
+		if !ok {
+			log.Fatalf("Unknown language %q", *language)
+		}
+		langCode = lang.Code
+	}
+
 	// Load the CTranslate2 library
 	if err := ctranslate2ffi.Load(*libPath); err != nil {
 		log.Fatalf("Failed to load CTranslate2 library: %v", err)
@@ -49,50 +79,26 @@ func main() {
 	fmt.Printf("CTranslate2 version: %s\n", ctranslate2ffi.Version())
 	fmt.Printf("CUDA available: %v\n", ctranslate2ffi.CUDAAvailable())
 
-	// Convert MP3 to WAV if needed
-	wavFile := *audioFile
-	if strings.HasSuffix(strings.ToLower(*audioFile), ".mp3") {
-		var err error
-		wavFile, err = convertMP3ToWAV(*audioFile)
-		if err != nil {
-			log.Fatalf("Failed to convert MP3 to WAV: %v", err)
-		}
-		defer os.Remove(wavFile) // Clean up temp file
-	}
-
-	// Load audio samples
-	samples, sampleRate, err := loadWAV(wavFile)
+	// Load audio samples, decoding the file in process. Each track is
+	// transcribed on its own: the mixed or selected channel, or every
+	// channel with -split-channels
+	tracks, sampleRate, err := loadAudio(*audioFile, *channel, *splitChannels)
 	if err != nil {
 		log.Fatalf("Failed to load audio: %v", err)
 	}
-	fmt.Printf("Loaded %d samples at %d Hz (%.2f seconds)\n",
-		len(samples), sampleRate, float64(len(samples))/float64(sampleRate))
+	fmt.Printf("Loaded %d track(s) of %d samples at %d Hz (%.2f seconds)\n",
+		len(tracks), len(tracks[0]), sampleRate, float64(len(tracks[0]))/float64(sampleRate))
 
 	// Resample to 16kHz if needed (Whisper expects 16kHz)
 	if sampleRate != 16000 {
-		samples = resample(samples, sampleRate, 16000)
-		sampleRate = 16000
-		fmt.Printf("Resampled to %d samples at %d Hz\n", len(samples), sampleRate)
-	}
-
-	// Compute mel spectrogram features
-	// Whisper expects 80 mel bands, 3000 time frames for 30 seconds of audio
-	melFeatures, err := computeMelSpectrogram(samples, sampleRate, 80)
-	if err != nil {
-		log.Fatalf("Failed to compute mel spectrogram: %v", err)
-	}
-	fmt.Printf("Computed mel spectrogram: %d mel bands x %d time frames\n",
-		len(melFeatures), len(melFeatures[0]))
-
-	// Flatten mel spectrogram for CTranslate2
-	// Shape: [1, n_mels, time_frames]
-	nMels := len(melFeatures)
-	timeFrames := len(melFeatures[0])
-	flatMel := make([]float32, nMels*timeFrames)
-	for i := 0; i < nMels; i++ {
-		for j := 0; j < timeFrames; j++ {
-			flatMel[i*timeFrames+j] = float32(melFeatures[i][j])
+		for i := range tracks {
+			tracks[i], err = audio.Resample(tracks[i], sampleRate, 16000)
+			if err != nil {
+				log.Fatalf("Failed to resample audio: %v", err)
+			}
 		}
+		sampleRate = 16000
+		fmt.Printf("Resampled to %d samples at %d Hz\n", len(tracks[0]), sampleRate)
 	}
 
 	// Load Whisper model
@@ -110,250 +116,174 @@ func main() {
 	fmt.Printf("Model loaded - Multilingual: %v, Mels: %d, Languages: %d\n",
 		whisper.IsMultilingual(), whisper.NumMels(), whisper.NumLanguages())
 
-	// Create storage view for mel features
-	shape := []int64{1, int64(nMels), int64(timeFrames)}
-	features, err := ctranslate2ffi.NewStorageViewFloat(flatMel, shape, ctranslate2ffi.DeviceCPU)
-	if err != nil {
-		log.Fatalf("Failed to create storage view: %v", err)
+	if langCode != "" {
+		if err := whisper.ValidateLanguage(langCode); err != nil {
+			log.Fatalf("Invalid language: %v", err)
+		}
 	}
-	defer features.Close()
-
-	// Prepare prompts (Whisper special tokens)
-	prompts := []string{
-		"<|startoftranscript|>",
-		"<|" + *language + "|>",
-		"<|transcribe|>",
-		"<|notimestamps|>",
+
+	// Compute mel spectrogram features for the whole recording with the
+	// number of mel bands the model expects (80, or 128 for large-v3),
+	// 100 time frames per second of audio
+	nMels := whisper.NumMels()
+	mels := make([][]float32, len(tracks))
+	for i, samples := range tracks {
+		mels[i], err = mel.LogMel(samples, nMels)
+		if err != nil {
+			log.Fatalf("Failed to compute mel spectrogram: %v", err)
+		}
 	}
+	fmt.Printf("Computed mel spectrogram: %d mel bands x %d time frames\n",
+		nMels, len(mels[0])/nMels)
 
-	// Transcribe
+	// Transcribe in 30-second windows; the language is detected from the
+	// first window unless one was given
 	fmt.Println("Transcribing...")
-	opts := ctranslate2ffi.DefaultWhisperOptions()
-	opts.BeamSize = 5
-	opts.ReturnScores = true
+	opts := ctranslate2ffi.DefaultTranscribeOptions()
+	opts.Language = langCode
+	opts.Whisper.BeamSize = 5
 
-	result, err := whisper.Generate(features, prompts, opts)
+	// Decode the model output to clean text with the model's vocabulary
+	tokenizer, err := ctranslate2ffi.LoadTokenizer(*modelPath)
 	if err != nil {
-		log.Fatalf("Transcription failed: %v", err)
+		fmt.Printf("Tokenizer not loaded, printing raw tokens: %v\n", err)
+	} else {
+		opts.Tokenizer = tokenizer
 	}
 
-	// Output transcription
-	fmt.Println("\n=== Transcription ===")
-	for _, seq := range result.Sequences {
-		fmt.Println(seq)
-	}
-	if len(result.Scores) > 0 {
-		fmt.Printf("\nScore: %.4f\n", result.Scores[0])
+	if *initialPrompt != "" {
+		if tokenizer == nil {
+			log.Fatal("An initial prompt needs the model vocabulary")
+		}
+		opts.InitialPrompt, err = tokenizer.EncodeTokens(" " + strings.TrimSpace(*initialPrompt))
+		if err != nil {
+			log.Fatalf("Failed to encode the initial prompt: %v", err)
+		}
 	}
-}
-
-// convertMP3ToWAV converts an MP3 file to WAV using ffmpeg
-func convertMP3ToWAV(mp3Path string) (string, error) {
-	wavPath := filepath.Join(os.TempDir(), "whisper_temp.wav")
 
-	cmd := exec.Command("ffmpeg", "-y", "-i", mp3Path,
-		"-ar", "16000", // Resample to 16kHz
-		"-ac", "1", // Mono
-		"-f", "wav",
-		wavPath)
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("ffmpeg error: %w\n%s", err, output)
+	if *wordTimestamps {
+		if tokenizer == nil {
+			log.Fatal("Word timestamps need the model vocabulary")
+		}
+		opts.WordTimestamps = true
 	}
 
-	return wavPath, nil
-}
-
-// loadWAV loads a WAV file and returns samples as float32 normalized to [-1, 1]
-func loadWAV(path string) ([]float32, int, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, 0, err
+	// Only decode the speech regions of each track
+	if *useVAD {
+		detector := vad.NewEnergyDetector()
+		for i, samples := range tracks {
+			regions, err := detector.Detect(samples, sampleRate)
+			if err != nil {
+				log.Fatalf("Voice activity detection failed: %v", err)
+			}
+			fmt.Printf("Track %d: %d speech region(s)\n", i, len(regions))
+			opts.ChannelSpeech = append(opts.ChannelSpeech, regions)
+		}
+		opts.Speech = opts.ChannelSpeech[0]
 	}
-	defer f.Close()
 
-	decoder := wav.NewDecoder(f)
-	if !decoder.IsValidFile() {
-		return nil, 0, fmt.Errorf("invalid WAV file")
+	var transcript *ctranslate2ffi.Transcript
+	if *splitChannels {
+		var names []string
+		if *speakers != "" {
+			for _, name := range strings.Split(*speakers, ",") {
+				names = append(names, strings.TrimSpace(name))
+			}
+		}
+		transcript, err = whisper.TranscribeChannels(mels, names, opts)
+	} else {
+		transcript, err = whisper.Transcribe(mels[0], opts)
 	}
-
-	buf, err := decoder.FullPCMBuffer()
 	if err != nil {
-		return nil, 0, err
+		log.Fatalf("Transcription failed: %v", err)
 	}
-
-	// Convert to float32
-	samples := make([]float32, len(buf.Data))
-	bitDepth := decoder.BitDepth
-	maxVal := float32(int(1)<<(bitDepth-1) - 1)
-
-	for i, s := range buf.Data {
-		samples[i] = float32(s) / maxVal
+	if lang, ok := ctranslate2ffi.LookupLanguage(transcript.Language); ok {
+		fmt.Printf("Language: %s (%s)\n", lang.Code, lang.Name)
 	}
 
-	return samples, int(decoder.SampleRate), nil
-}
-
-// resample resamples audio from srcRate to dstRate using linear interpolation
-func resample(samples []float32, srcRate, dstRate int) []float32 {
-	if srcRate == dstRate {
-		return samples
+	// Write subtitles or data files
+	if writer != nil {
+		path := *output
+		if path == "" {
+			path = strings.TrimSuffix(*audioFile, filepath.Ext(*audioFile)) + "." + strings.ToLower(*format)
+		}
+		if err := writeTranscript(path, writer, transcript); err != nil {
+			log.Fatalf("Failed to write %s: %v", path, err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+		return
 	}
 
-	ratio := float64(srcRate) / float64(dstRate)
-	newLen := int(float64(len(samples)) / ratio)
-	result := make([]float32, newLen)
-
-	for i := 0; i < newLen; i++ {
-		srcIdx := float64(i) * ratio
-		srcIdxInt := int(srcIdx)
-		frac := float32(srcIdx - float64(srcIdxInt))
-
-		if srcIdxInt+1 < len(samples) {
-			result[i] = samples[srcIdxInt]*(1-frac) + samples[srcIdxInt+1]*frac
-		} else if srcIdxInt < len(samples) {
-			result[i] = samples[srcIdxInt]
+	// Output transcription
+	fmt.Println("\n=== Transcription ===")
+	for _, seg := range transcript.Segments {
+		if *splitChannels {
+			fmt.Printf("[%s -> %s] %s: %s\n", formatDuration(seg.Start.Seconds()), formatDuration(seg.End.Seconds()), speakerLabel(seg), seg.Text)
+		} else {
+			fmt.Printf("[%s -> %s] %s\n", formatDuration(seg.Start.Seconds()), formatDuration(seg.End.Seconds()), seg.Text)
+		}
+		for _, word := range seg.Words {
+			fmt.Printf("    [%s -> %s] %s (%.2f)\n", formatDuration(word.Start.Seconds()), formatDuration(word.End.Seconds()), strings.TrimSpace(word.Text), word.Probability)
 		}
 	}
-
-	return result
 }
 
-// computeMelSpectrogram computes a log mel spectrogram from audio samples
-// This is a simplified implementation - for production, use a proper DSP library
-func computeMelSpectrogram(samples []float32, sampleRate, nMels int) ([][]float64, error) {
-	// Whisper parameters
-	nFFT := 400       // 25ms window at 16kHz
-	hopLength := 160  // 10ms hop at 16kHz
-	maxFrames := 3000 // 30 seconds
-
-	// Calculate number of frames
-	nFrames := (len(samples) - nFFT) / hopLength
-	if nFrames > maxFrames {
-		nFrames = maxFrames
-	}
-	if nFrames < 1 {
-		nFrames = 1
+// loadAudio loads an audio file in any format known to the audio package
+// and returns the tracks to transcribe: every channel when split is set, the
+// selected channel, or all channels mixed down when channel is negative
+func loadAudio(path string, channel int, split bool) ([][]float32, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
 	}
+	defer f.Close()
 
-	// Create mel filterbank
-	melFilters := createMelFilterbank(nMels, nFFT, sampleRate)
-
-	// Initialize mel spectrogram
-	melSpec := make([][]float64, nMels)
-	for i := range melSpec {
-		melSpec[i] = make([]float64, nFrames)
+	pcm, _, err := audio.Decode(f)
+	if err != nil {
+		return nil, 0, err
 	}
-
-	// Hann window
-	window := make([]float64, nFFT)
-	for i := 0; i < nFFT; i++ {
-		window[i] = 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(nFFT-1)))
+	if pcm.Len() == 0 {
+		return nil, 0, errors.New("audio file has no samples")
 	}
 
-	// Process each frame
-	for frame := 0; frame < nFrames; frame++ {
-		start := frame * hopLength
-		if start+nFFT > len(samples) {
-			break
-		}
-
-		// Apply window and compute FFT magnitude
-		magnitudes := make([]float64, nFFT/2+1)
-		for i := 0; i < nFFT/2+1; i++ {
-			var re, im float64
-			for j := 0; j < nFFT; j++ {
-				if start+j < len(samples) {
-					x := float64(samples[start+j]) * window[j]
-					angle := -2 * math.Pi * float64(i) * float64(j) / float64(nFFT)
-					re += x * math.Cos(angle)
-					im += x * math.Sin(angle)
-				}
-			}
-			magnitudes[i] = math.Sqrt(re*re + im*im)
-		}
+	switch {
+	case split:
+		return pcm.Channels, pcm.SampleRate, nil
 
-		// Apply mel filterbank
-		for m := 0; m < nMels; m++ {
-			var energy float64
-			for k := 0; k < len(magnitudes); k++ {
-				energy += magnitudes[k] * magnitudes[k] * melFilters[m][k]
-			}
-			// Log mel spectrogram
-			if energy > 1e-10 {
-				melSpec[m][frame] = math.Log(energy)
-			} else {
-				melSpec[m][frame] = math.Log(1e-10)
-			}
-		}
+	case channel < 0:
+		return [][]float32{pcm.Mono()}, pcm.SampleRate, nil
 	}
 
-	// Normalize (Whisper-style)
-	maxVal := -1e10
-	for m := 0; m < nMels; m++ {
-		for f := 0; f < nFrames; f++ {
-			if melSpec[m][f] > maxVal {
-				maxVal = melSpec[m][f]
-			}
-		}
-	}
-	for m := 0; m < nMels; m++ {
-		for f := 0; f < nFrames; f++ {
-			melSpec[m][f] = (melSpec[m][f] - maxVal) / 4.0
-			if melSpec[m][f] < -1.0 {
-				melSpec[m][f] = -1.0
-			}
-		}
+	samples, err := pcm.Channel(channel)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return melSpec, nil
+	return [][]float32{samples}, pcm.SampleRate, nil
 }
 
-// createMelFilterbank creates a mel filterbank matrix
-func createMelFilterbank(nMels, nFFT, sampleRate int) [][]float64 {
-	fMin := 0.0
-	fMax := float64(sampleRate) / 2.0
-
-	// Convert to mel scale
-	melMin := 2595.0 * math.Log10(1.0+fMin/700.0)
-	melMax := 2595.0 * math.Log10(1.0+fMax/700.0)
-
-	// Create mel points
-	melPoints := make([]float64, nMels+2)
-	for i := 0; i <= nMels+1; i++ {
-		melPoints[i] = melMin + float64(i)*(melMax-melMin)/float64(nMels+1)
+// writeTranscript writes the transcript to a file in the writer's format
+func writeTranscript(path string, writer subtitle.Writer, transcript *ctranslate2ffi.Transcript) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
 	}
 
-	// Convert back to Hz
-	hzPoints := make([]float64, nMels+2)
-	for i := range hzPoints {
-		hzPoints[i] = 700.0 * (math.Pow(10, melPoints[i]/2595.0) - 1.0)
+	if err := writer.Write(f, transcript); err != nil {
+		f.Close()
+		return err
 	}
 
-	// Convert to FFT bin indices
-	binPoints := make([]int, nMels+2)
-	for i := range binPoints {
-		binPoints[i] = int(math.Floor((float64(nFFT) + 1) * hzPoints[i] / float64(sampleRate)))
-	}
+	return f.Close()
+}
 
-	// Create filterbank
-	filterbank := make([][]float64, nMels)
-	for m := 0; m < nMels; m++ {
-		filterbank[m] = make([]float64, nFFT/2+1)
-		for k := binPoints[m]; k < binPoints[m+1]; k++ {
-			if k < nFFT/2+1 {
-				filterbank[m][k] = float64(k-binPoints[m]) / float64(binPoints[m+1]-binPoints[m])
-			}
-		}
-		for k := binPoints[m+1]; k < binPoints[m+2]; k++ {
-			if k < nFFT/2+1 {
-				filterbank[m][k] = float64(binPoints[m+2]-k) / float64(binPoints[m+2]-binPoints[m+1])
-			}
-		}
+// speakerLabel names the source of a segment, falling back to the channel
+// number when no speaker name was given
+func speakerLabel(seg ctranslate2ffi.Segment) string {
+	if seg.Speaker != "" {
+		return seg.Speaker
 	}
-
-	return filterbank
+	return "ch" + strconv.Itoa(seg.Channel)
 }
 
 // Helper to format duration
@@ -362,6 +292,3 @@ func formatDuration(seconds float64) string {
 	secs := seconds - float64(mins*60)
 	return strconv.Itoa(mins) + "m" + strconv.FormatFloat(secs, 'f', 1, 64) + "s"
 }
-
-// Placeholder for audio buffer interface
-var _ = audio.IntBuffer{}
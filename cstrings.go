@@ -0,0 +1,88 @@
+package ctranslate2ffi
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// cStrings converts strs into NUL-terminated C strings. The returned slice
+// owns the memory and must be kept alive until the C call returns.
+func cStrings(strs []string) []*byte {
+	ptrs := make([]*byte, len(strs))
+	for i, s := range strs {
+		b := append([]byte(s), 0)
+		ptrs[i] = &b[0]
+	}
+
+	return ptrs
+}
+
+// cStringBatch converts a ragged batch of token lists into the char*** and
+// size_t* layout expected by the batch functions. Both returned slices own
+// the memory and must be kept alive until the C call returns.
+func cStringBatch(batch [][]string) ([]**byte, []uint64) {
+	ptrs := make([]**byte, len(batch))
+	lengths := make([]uint64, len(batch))
+	for i, strs := range batch {
+		if len(strs) > 0 {
+			ptrs[i] = &cStrings(strs)[0]
+		}
+		lengths[i] = uint64(len(strs))
+	}
+
+	return ptrs, lengths
+}
+
+// goStrings copies n NUL-terminated C strings from a char** array.
+func goStrings(p **byte, n uint64) []string {
+	if p == nil || n == 0 {
+		return nil
+	}
+
+	ptrs := unsafe.Slice(p, n)
+	strs := make([]string, n)
+	for i, s := range ptrs {
+		if s != nil {
+			strs[i] = unix.BytePtrToString(s)
+		}
+	}
+
+	return strs
+}
+
+// goFloats copies n floats from a C float array.
+func goFloats(p *float32, n uint64) []float32 {
+	if p == nil || n == 0 {
+		return nil
+	}
+
+	floats := make([]float32, n)
+	copy(floats, unsafe.Slice(p, n))
+
+	return floats
+}
+
+// goInts copies a C array of size_t values.
+func goInts(p *uint64, n uint64) []int {
+	if p == nil || n == 0 {
+		return nil
+	}
+
+	ints := make([]int, n)
+	for i, v := range unsafe.Slice(p, n) {
+		ints[i] = int(v)
+	}
+
+	return ints
+}
+
+// cSizes converts ints to a C array of size_t values.
+func cSizes(ints []int) []uint64 {
+	sizes := make([]uint64, len(ints))
+	for i, v := range ints {
+		sizes[i] = uint64(v)
+	}
+
+	return sizes
+}
@@ -0,0 +1,136 @@
+package ctranslate2ffi
+
+import (
+	"bytes"
+	"compress/zlib"
+	"math"
+	"strings"
+)
+
+// windowDecode is the result of decoding a window with temperature fallback.
+type windowDecode struct {
+	result      *WhisperResult
+	sequence    string
+	temperature float32
+	avgLogProb  float64
+	silent      bool
+}
+
+// decodeWindow decodes a window at each of opts.Temperatures in turn until
+// the output passes the quality checks, the way reference Whisper does. A
+// decode fails when its text compresses too well, which means it repeats
+// itself, or when its average log probability is too low. A window the model
+// considers silent is not retried. Without temperatures, the window is
+// decoded once with opts.Whisper.
+func (w *Whisper) decodeWindow(features *StorageView, prompts []string, opts TranscribeOptions) (*windowDecode, error) {
+	temperatures := windowTemperatures(opts)
+
+	var d *windowDecode
+	for i, t := range temperatures {
+		wopts := opts.Whisper
+		wopts.ReturnScores = true
+		wopts.ReturnNoSpeechProb = true
+		if len(opts.Temperatures) > 0 {
+			wopts.SamplingTemperature = t
+			if t > 0 {
+				// Sample from the full distribution instead of searching
+				wopts.BeamSize = 1
+				wopts.SamplingTopK = 0
+			} else {
+				wopts.SamplingTopK = 1
+			}
+		}
+
+		result, err := w.Generate(features, prompts, wopts)
+		if err != nil {
+			return nil, err
+		}
+
+		d = &windowDecode{
+			result:      result,
+			temperature: t,
+		}
+		if len(result.Sequences) > 0 {
+			d.sequence = result.Sequences[0]
+		}
+		if len(result.Scores) > 0 {
+			d.avgLogProb = avgLogProb(result.Scores[0], len(strings.Fields(d.sequence)), wopts.LengthPenalty)
+		}
+
+		// Silence is skipped by the caller, so decoding it again at a
+		// higher temperature would only waste time
+		d.silent = opts.NoSpeechThreshold > 0 && result.NoSpeechProb > opts.NoSpeechThreshold
+		if opts.LogProbThreshold != nil && d.avgLogProb > float64(*opts.LogProbThreshold) {
+			d.silent = false
+		}
+
+		if i == len(temperatures)-1 || d.silent {
+			break
+		}
+
+		// The compression ratio is measured on the text, as the thresholds
+		// of reference Whisper expect, even without a vocabulary
+		text := decodeBytes(sequenceTokens(d.sequence))
+		if opts.Tokenizer != nil {
+			text = opts.Tokenizer.DecodeSequence(d.sequence)
+		}
+		needsFallback := (opts.CompressionRatioThreshold > 0 && compressionRatio(text) > float64(opts.CompressionRatioThreshold)) ||
+			(opts.LogProbThreshold != nil && d.avgLogProb < float64(*opts.LogProbThreshold))
+		if !needsFallback {
+			break
+		}
+	}
+
+	return d, nil
+}
+
+// windowTemperatures returns the temperatures a window is decoded at in
+// turn. Without opts.Temperatures the window is decoded once with
+// opts.Whisper, whose temperature does not matter when only the most likely
+// token is sampled: that decode is greedy, at temperature 0.
+func windowTemperatures(opts TranscribeOptions) []float32 {
+	if len(opts.Temperatures) > 0 {
+		return opts.Temperatures
+	}
+
+	if opts.Whisper.SamplingTopK == 1 {
+		return []float32{0}
+	}
+
+	return []float32{opts.Whisper.SamplingTemperature}
+}
+
+// resetsConditioning reports whether the text of a window decoded at
+// temperature must not condition the next window. Text sampled at a high
+// temperature is likely poor, so it should not steer the next window.
+func resetsConditioning(opts TranscribeOptions, temperature float32) bool {
+	return !opts.ConditionOnPreviousText || temperature > 0.5
+}
+
+// avgLogProb recovers the average log probability per token from the score
+// of a hypothesis, which CTranslate2 normalizes by length raised to the
+// length penalty. The end-of-text token counts as one more token.
+func avgLogProb(score float32, tokens int, lengthPenalty float32) float64 {
+	n := float64(tokens)
+	cumulative := float64(score)
+	if lengthPenalty != 0 {
+		cumulative *= math.Pow(n, float64(lengthPenalty))
+	}
+
+	return cumulative / (n + 1)
+}
+
+// compressionRatio returns how many times text shrinks when compressed.
+// Decodes stuck in a loop repeat themselves and compress unusually well.
+func compressionRatio(text string) float64 {
+	if text == "" {
+		return 0
+	}
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte(text))
+	zw.Close()
+
+	return float64(len(text)) / float64(buf.Len())
+}
@@ -0,0 +1,93 @@
+package ctranslate2ffi
+
+import "testing"
+
+func TestDecodeBytes(t *testing.T) {
+	seq := "<|0.00|> ĠHello Ġworld ! Ċ Ġcaf Ã© <|1.00|>"
+	if got, want := decodeBytes(sequenceTokens(seq)), " Hello world!\n café"; got != want {
+		t.Errorf("decodeBytes = %q, want %q", got, want)
+	}
+}
+
+func TestCompressionRatio(t *testing.T) {
+	if got := compressionRatio(""); got != 0 {
+		t.Errorf("compressionRatio of empty text = %v, want 0", got)
+	}
+
+	normal := " The quick brown fox jumps over the lazy dog."
+	if got := compressionRatio(normal); got > 2.4 {
+		t.Errorf("compressionRatio(%q) = %v, want at most 2.4", normal, got)
+	}
+
+	var loop string
+	for range 20 {
+		loop += " Thank you."
+	}
+	if got := compressionRatio(loop); got <= 2.4 {
+		t.Errorf("compressionRatio of a repetition loop = %v, want above 2.4", got)
+	}
+}
+
+func TestResetsConditioning(t *testing.T) {
+	// decodeAt returns the temperature of the last decode of a window that
+	// fails the quality checks at every temperature
+	decodeAt := func(opts TranscribeOptions) float32 {
+		temps := windowTemperatures(opts)
+		return temps[len(temps)-1]
+	}
+
+	defaults := DefaultTranscribeOptions()
+
+	noFallback := DefaultTranscribeOptions()
+	noFallback.Temperatures = nil
+
+	sampled := noFallback
+	sampled.Whisper.SamplingTopK = 0
+	sampled.Whisper.SamplingTemperature = 0.8
+
+	noConditioning := DefaultTranscribeOptions()
+	noConditioning.ConditionOnPreviousText = false
+
+	tests := []struct {
+		name        string
+		opts        TranscribeOptions
+		temperature float32
+		want        bool
+	}{
+		{name: "first temperature", opts: defaults, temperature: defaults.Temperatures[0], want: false},
+		{name: "low fallback temperature", opts: defaults, temperature: 0.4, want: false},
+		{name: "high fallback temperature", opts: defaults, temperature: 0.6, want: true},
+		{name: "last fallback temperature", opts: defaults, temperature: decodeAt(defaults), want: true},
+		{name: "greedy without fallback", opts: noFallback, temperature: decodeAt(noFallback), want: false},
+		{name: "sampled without fallback", opts: sampled, temperature: decodeAt(sampled), want: true},
+		{name: "conditioning disabled", opts: noConditioning, temperature: 0, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resetsConditioning(tt.opts, tt.temperature); got != tt.want {
+				t.Errorf("resetsConditioning at %v = %v, want %v", tt.temperature, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowTemperatures(t *testing.T) {
+	opts := DefaultTranscribeOptions()
+	if got := windowTemperatures(opts); len(got) != 6 || got[0] != 0 || got[5] != 1 {
+		t.Errorf("windowTemperatures with fallback = %v", got)
+	}
+
+	// The default Whisper options sample at temperature 1 from the top
+	// token only, which is greedy decoding
+	opts.Temperatures = nil
+	if got := windowTemperatures(opts); len(got) != 1 || got[0] != 0 {
+		t.Errorf("windowTemperatures of greedy decoding = %v, want [0]", got)
+	}
+
+	opts.Whisper.SamplingTopK = 0
+	opts.Whisper.SamplingTemperature = 0.7
+	if got := windowTemperatures(opts); len(got) != 1 || got[0] != 0.7 {
+		t.Errorf("windowTemperatures of sampling = %v, want [0.7]", got)
+	}
+}
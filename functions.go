@@ -11,41 +11,46 @@ import (
 var _ = unix.BytePtrFromString
 
 var (
-	ct2GetLastErrorFunc              ffi.Fun
-	ct2ClearErrorFunc                ffi.Fun
-	ct2ModelConfigDefaultFunc        ffi.Fun
-	ct2StringsFreeFunc               ffi.Fun
-	ct2FloatsFreeFunc                ffi.Fun
-	ct2StorageCreateFloatFunc        ffi.Fun
-	ct2StorageGetShapeFunc           ffi.Fun
-	ct2StorageSizeFunc               ffi.Fun
-	ct2StorageToFloatFunc            ffi.Fun
-	ct2StorageFreeFunc               ffi.Fun
-	ct2WhisperOptionsDefaultFunc     ffi.Fun
-	ct2WhisperResultFreeFunc         ffi.Fun
-	ct2WhisperCreateFunc             ffi.Fun
-	ct2WhisperIsMultilingualFunc     ffi.Fun
-	ct2WhisperNMelsFunc              ffi.Fun
-	ct2WhisperNumLanguagesFunc       ffi.Fun
-	ct2WhisperGenerateFunc           ffi.Fun
-	ct2WhisperDetectLanguageFunc     ffi.Fun
-	ct2WhisperEncodeFunc             ffi.Fun
-	ct2WhisperFreeFunc               ffi.Fun
-	ct2TranslationOptionsDefaultFunc ffi.Fun
-	ct2TranslationResultFreeFunc     ffi.Fun
-	ct2TranslatorCreateFunc          ffi.Fun
-	ct2TranslatorTranslateBatchFunc  ffi.Fun
-	ct2TranslatorTranslateFunc       ffi.Fun
-	ct2TranslatorFreeFunc            ffi.Fun
-	ct2GenerationOptionsDefaultFunc  ffi.Fun
-	ct2GenerationResultFreeFunc      ffi.Fun
-	ct2GeneratorCreateFunc           ffi.Fun
-	ct2GeneratorGenerateFunc         ffi.Fun
-	ct2GeneratorGenerateBatchFunc    ffi.Fun
-	ct2GeneratorFreeFunc             ffi.Fun
-	ct2VersionFunc                   ffi.Fun
-	ct2CudaAvailableFunc             ffi.Fun
-	ct2CudaDeviceCountFunc           ffi.Fun
+	ct2GetLastErrorFunc               ffi.Fun
+	ct2ClearErrorFunc                 ffi.Fun
+	ct2ModelConfigDefaultFunc         ffi.Fun
+	ct2StringsFreeFunc                ffi.Fun
+	ct2FloatsFreeFunc                 ffi.Fun
+	ct2StorageCreateFloatFunc         ffi.Fun
+	ct2StorageGetShapeFunc            ffi.Fun
+	ct2StorageSizeFunc                ffi.Fun
+	ct2StorageToFloatFunc             ffi.Fun
+	ct2StorageFreeFunc                ffi.Fun
+	ct2WhisperOptionsDefaultFunc      ffi.Fun
+	ct2WhisperResultFreeFunc          ffi.Fun
+	ct2WhisperCreateFunc              ffi.Fun
+	ct2WhisperIsMultilingualFunc      ffi.Fun
+	ct2WhisperNMelsFunc               ffi.Fun
+	ct2WhisperNumLanguagesFunc        ffi.Fun
+	ct2WhisperGenerateFunc            ffi.Fun
+	ct2WhisperGenerateBatchFunc       ffi.Fun
+	ct2WhisperGenerateBatchLoaded     bool
+	ct2WhisperAlignFunc               ffi.Fun
+	ct2WhisperAlignLoaded             bool
+	ct2WhisperAlignmentResultFreeFunc ffi.Fun
+	ct2WhisperDetectLanguageFunc      ffi.Fun
+	ct2WhisperEncodeFunc              ffi.Fun
+	ct2WhisperFreeFunc                ffi.Fun
+	ct2TranslationOptionsDefaultFunc  ffi.Fun
+	ct2TranslationResultFreeFunc      ffi.Fun
+	ct2TranslatorCreateFunc           ffi.Fun
+	ct2TranslatorTranslateBatchFunc   ffi.Fun
+	ct2TranslatorTranslateFunc        ffi.Fun
+	ct2TranslatorFreeFunc             ffi.Fun
+	ct2GenerationOptionsDefaultFunc   ffi.Fun
+	ct2GenerationResultFreeFunc       ffi.Fun
+	ct2GeneratorCreateFunc            ffi.Fun
+	ct2GeneratorGenerateFunc          ffi.Fun
+	ct2GeneratorGenerateBatchFunc     ffi.Fun
+	ct2GeneratorFreeFunc              ffi.Fun
+	ct2VersionFunc                    ffi.Fun
+	ct2CudaAvailableFunc              ffi.Fun
+	ct2CudaDeviceCountFunc            ffi.Fun
 )
 
 func loadFuncs() error {
@@ -119,6 +124,20 @@ func loadFuncs() error {
 		return fmt.Errorf("ct2_whisper_generate: %w", err)
 	}
 
+	// ct2_whisper_generate_batch is an extension of the C API that older
+	// builds of the library do not export, so it is optional
+	ct2WhisperGenerateBatchFunc, err = lib.Prep("ct2_whisper_generate_batch", &ffi.TypeSint32, &ffi.TypePointer, &ffi.TypePointer, &ffi.TypePointer, &ffi.TypePointer, &ffi.TypeUint64, &FFITypeCt2whisperoptions, &ffi.TypePointer)
+	ct2WhisperGenerateBatchLoaded = err == nil
+
+	// ct2_whisper_align is an extension of the C API as well; its result
+	// is only usable along with ct2_whisper_alignment_result_free
+	ct2WhisperAlignFunc, err = lib.Prep("ct2_whisper_align", &ffi.TypeSint32, &ffi.TypePointer, &ffi.TypePointer, &ffi.TypePointer, &ffi.TypeUint64, &ffi.TypePointer, &ffi.TypeUint64, &ffi.TypeUint64, &ffi.TypeUint64, &ffi.TypePointer)
+	ct2WhisperAlignLoaded = err == nil
+	if ct2WhisperAlignLoaded {
+		ct2WhisperAlignmentResultFreeFunc, err = lib.Prep("ct2_whisper_alignment_result_free", &ffi.TypeVoid, &ffi.TypePointer)
+		ct2WhisperAlignLoaded = err == nil
+	}
+
 	if ct2WhisperDetectLanguageFunc, err = lib.Prep("ct2_whisper_detect_language", &ffi.TypeSint32, &ffi.TypePointer, &ffi.TypePointer, &ffi.TypePointer, &ffi.TypePointer); err != nil {
 		return fmt.Errorf("ct2_whisper_detect_language: %w", err)
 	}
@@ -290,6 +309,33 @@ func Ct2WhisperGenerate(whisper Ct2whisper, features Ct2storageview, prompts uin
 	return int32(ret)
 }
 
+func Ct2WhisperGenerateBatch(whisper Ct2whisper, features Ct2storageview, prompts uintptr, promptLengths uintptr, numPrompts uint64, options Ct2whisperoptions, results *Ct2whisperresult) int32 {
+	if !ct2WhisperGenerateBatchLoaded {
+		return -1
+	}
+
+	var ret ffi.Arg
+	ct2WhisperGenerateBatchFunc.Call(unsafe.Pointer(&ret), unsafe.Pointer(&whisper), unsafe.Pointer(&features), unsafe.Pointer(&prompts), unsafe.Pointer(&promptLengths), unsafe.Pointer(&numPrompts), &options, unsafe.Pointer(&results))
+	return int32(ret)
+}
+
+func Ct2WhisperAlign(whisper Ct2whisper, features Ct2storageview, startSequence uintptr, startSequenceLength uint64, textTokens uintptr, numTextTokens uint64, numFrames uint64, medianFilterWidth uint64, result *Ct2whisperalignmentresult) int32 {
+	if !ct2WhisperAlignLoaded {
+		return -1
+	}
+
+	var ret ffi.Arg
+	ct2WhisperAlignFunc.Call(unsafe.Pointer(&ret), unsafe.Pointer(&whisper), unsafe.Pointer(&features), unsafe.Pointer(&startSequence), unsafe.Pointer(&startSequenceLength), unsafe.Pointer(&textTokens), unsafe.Pointer(&numTextTokens), unsafe.Pointer(&numFrames), unsafe.Pointer(&medianFilterWidth), unsafe.Pointer(&result))
+	return int32(ret)
+}
+
+func Ct2WhisperAlignmentResultFree(result *Ct2whisperalignmentresult) {
+	if !ct2WhisperAlignLoaded {
+		return
+	}
+	ct2WhisperAlignmentResultFreeFunc.Call(nil, unsafe.Pointer(&result))
+}
+
 func Ct2WhisperDetectLanguage(whisper Ct2whisper, features Ct2storageview, languages *Ct2stringarray, probabilities *Ct2floatarray) int32 {
 	var result ffi.Arg
 	ct2WhisperDetectLanguageFunc.Call(unsafe.Pointer(&result), unsafe.Pointer(&whisper), unsafe.Pointer(&features), unsafe.Pointer(&languages), unsafe.Pointer(&probabilities))
@@ -323,10 +369,9 @@ func Ct2TranslatorCreate(modelPath string, config Ct2modelconfig) Ct2translator
 	return result
 }
 
-func Ct2TranslatorTranslateBatch(translator Ct2translator, sources string, sourceLengths uintptr, numSources uint64, options Ct2translationoptions, results *Ct2translationresult) int32 {
-	sourcesPtr, _ := unix.BytePtrFromString(sources)
+func Ct2TranslatorTranslateBatch(translator Ct2translator, sources uintptr, sourceLengths uintptr, numSources uint64, options Ct2translationoptions, results *Ct2translationresult) int32 {
 	var result ffi.Arg
-	ct2TranslatorTranslateBatchFunc.Call(unsafe.Pointer(&result), unsafe.Pointer(&translator), unsafe.Pointer(&sourcesPtr), unsafe.Pointer(&sourceLengths), unsafe.Pointer(&numSources), &options, unsafe.Pointer(&results))
+	ct2TranslatorTranslateBatchFunc.Call(unsafe.Pointer(&result), unsafe.Pointer(&translator), unsafe.Pointer(&sources), unsafe.Pointer(&sourceLengths), unsafe.Pointer(&numSources), &options, unsafe.Pointer(&results))
 	return int32(result)
 }
 
@@ -363,10 +408,9 @@ func Ct2GeneratorGenerate(generator Ct2generator, prompt uintptr, promptLength u
 	return int32(ret)
 }
 
-func Ct2GeneratorGenerateBatch(generator Ct2generator, prompts string, promptLengths uintptr, numPrompts uint64, options Ct2generationoptions, results *Ct2generationresult) int32 {
-	promptsPtr, _ := unix.BytePtrFromString(prompts)
+func Ct2GeneratorGenerateBatch(generator Ct2generator, prompts uintptr, promptLengths uintptr, numPrompts uint64, options Ct2generationoptions, results *Ct2generationresult) int32 {
 	var result ffi.Arg
-	ct2GeneratorGenerateBatchFunc.Call(unsafe.Pointer(&result), unsafe.Pointer(&generator), unsafe.Pointer(&promptsPtr), unsafe.Pointer(&promptLengths), unsafe.Pointer(&numPrompts), &options, unsafe.Pointer(&results))
+	ct2GeneratorGenerateBatchFunc.Call(unsafe.Pointer(&result), unsafe.Pointer(&generator), unsafe.Pointer(&prompts), unsafe.Pointer(&promptLengths), unsafe.Pointer(&numPrompts), &options, unsafe.Pointer(&results))
 	return int32(result)
 }
 
@@ -2,6 +2,7 @@ package ctranslate2ffi
 
 import (
 	"errors"
+	"runtime"
 	"unsafe"
 )
 
@@ -62,7 +63,9 @@ func (o GenerationOptions) toC() Ct2generationoptions {
 	}
 }
 
-// GenerationResult holds the result of text generation.
+// GenerationResult holds the result of text generation. Sequences holds one
+// token list per hypothesis, best first. When IncludePromptInResult is set the
+// prompt tokens are included at the start of every sequence.
 type GenerationResult struct {
 	Sequences [][]string
 	Scores    []float32
@@ -100,16 +103,17 @@ func (g *Generator) Generate(prompt []string, opts GenerationOptions) (*Generati
 		return nil, errors.New("generator is closed")
 	}
 
-	// Convert prompt to C strings
-	cStrings := make([]*byte, len(prompt))
-	for i, tok := range prompt {
-		b := append([]byte(tok), 0)
-		cStrings[i] = &b[0]
+	if len(prompt) == 0 {
+		return nil, errors.New("prompt cannot be empty")
 	}
-	promptPtr := uintptr(unsafe.Pointer(&cStrings[0]))
+
+	// Convert prompt to C strings
+	cPrompt := cStrings(prompt)
+	promptPtr := uintptr(unsafe.Pointer(&cPrompt[0]))
 
 	var result Ct2generationresult
 	ret := Ct2GeneratorGenerate(g.handle, promptPtr, uint64(len(prompt)), opts.toC(), &result)
+	runtime.KeepAlive(cPrompt)
 	if ret != 0 {
 		errMsg := Ct2GetLastError()
 		if errMsg == "" {
@@ -118,9 +122,68 @@ func (g *Generator) Generate(prompt []string, opts GenerationOptions) (*Generati
 		return nil, errors.New(errMsg)
 	}
 
-	gr := &GenerationResult{}
-	// TODO: Properly extract sequences from the raw pointers
+	gr := newGenerationResult(&result, opts)
 
 	Ct2GenerationResultFree(&result)
 	return gr, nil
 }
+
+// GenerateBatch generates continuations for a batch of prompts in a single
+// call so CTranslate2 can batch them internally. It returns one result per
+// prompt, in the same order as prompts.
+func (g *Generator) GenerateBatch(prompts [][]string, opts GenerationOptions) ([]GenerationResult, error) {
+	if g.handle == 0 {
+		return nil, errors.New("generator is closed")
+	}
+
+	if len(prompts) == 0 {
+		return nil, errors.New("prompts cannot be empty")
+	}
+
+	// Convert prompts to a ragged array of C strings
+	cPrompts, lengths := cStringBatch(prompts)
+	promptsPtr := uintptr(unsafe.Pointer(&cPrompts[0]))
+	lengthsPtr := uintptr(unsafe.Pointer(&lengths[0]))
+
+	results := make([]Ct2generationresult, len(prompts))
+	ret := Ct2GeneratorGenerateBatch(g.handle, promptsPtr, lengthsPtr, uint64(len(prompts)), opts.toC(), &results[0])
+	runtime.KeepAlive(cPrompts)
+	runtime.KeepAlive(lengths)
+	if ret != 0 {
+		errMsg := Ct2GetLastError()
+		if errMsg == "" {
+			errMsg = "batch generation failed"
+		}
+		return nil, errors.New(errMsg)
+	}
+
+	grs := make([]GenerationResult, len(results))
+	for i := range results {
+		grs[i] = *newGenerationResult(&results[i], opts)
+		Ct2GenerationResultFree(&results[i])
+	}
+
+	return grs, nil
+}
+
+// newGenerationResult copies the sequences and scores out of a C result.
+// It must be called before the result is freed.
+func newGenerationResult(result *Ct2generationresult, opts GenerationOptions) *GenerationResult {
+	gr := &GenerationResult{}
+
+	if result.Sequences != nil && result.SequenceLengths != nil && result.NumSequences > 0 {
+		seqs := unsafe.Slice(result.Sequences, result.NumSequences)
+		lengths := unsafe.Slice(result.SequenceLengths, result.NumSequences)
+
+		gr.Sequences = make([][]string, result.NumSequences)
+		for i := range seqs {
+			gr.Sequences[i] = goStrings(seqs[i], lengths[i])
+		}
+	}
+
+	if opts.ReturnScores {
+		gr.Scores = goFloats(result.Scores, result.NumScores)
+	}
+
+	return gr
+}
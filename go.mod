@@ -3,13 +3,17 @@ module github.com/ardanlabs/ctranslate2ffi
 go 1.25.6
 
 require (
-	github.com/go-audio/audio v1.0.0
-	github.com/go-audio/wav v1.1.0
+	github.com/hajimehoshi/go-mp3 v0.3.4
+	github.com/jfreymuth/oggvorbis v1.0.5
 	github.com/jupiterrider/ffi v0.5.1
+	github.com/mewkiz/flac v1.0.14
 	golang.org/x/sys v0.40.0
 )
 
 require (
 	github.com/ebitengine/purego v0.9.1 // indirect
-	github.com/go-audio/riff v1.0.0 // indirect
+	github.com/icza/bitio v1.1.0 // indirect
+	github.com/jfreymuth/vorbis v1.0.2 // indirect
+	github.com/mewkiz/pkg v0.0.0-20250417130911-3f050ff8c56d // indirect
+	github.com/mewpkg/term v0.0.0-20241026122259-37a80af23985 // indirect
 )
@@ -0,0 +1,130 @@
+package ctranslate2ffi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Language is a language supported by Whisper.
+type Language struct {
+	Code string // such as "en"
+	Name string // English name in lower case, such as "english"
+}
+
+// Token returns the language token of the decoder prompt, such as <|en|>.
+func (l Language) Token() string {
+	return "<|" + l.Code + "|>"
+}
+
+// languages lists the Whisper languages in the order of their tokens in the
+// vocabulary. Models support a prefix of this table: 99 languages, or 100
+// from large-v3 on, which added Cantonese.
+var languages = []Language{
+	{"en", "english"}, {"zh", "chinese"}, {"de", "german"}, {"es", "spanish"},
+	{"ru", "russian"}, {"ko", "korean"}, {"fr", "french"}, {"ja", "japanese"},
+	{"pt", "portuguese"}, {"tr", "turkish"}, {"pl", "polish"}, {"ca", "catalan"},
+	{"nl", "dutch"}, {"ar", "arabic"}, {"sv", "swedish"}, {"it", "italian"},
+	{"id", "indonesian"}, {"hi", "hindi"}, {"fi", "finnish"}, {"vi", "vietnamese"},
+	{"he", "hebrew"}, {"uk", "ukrainian"}, {"el", "greek"}, {"ms", "malay"},
+	{"cs", "czech"}, {"ro", "romanian"}, {"da", "danish"}, {"hu", "hungarian"},
+	{"ta", "tamil"}, {"no", "norwegian"}, {"th", "thai"}, {"ur", "urdu"},
+	{"hr", "croatian"}, {"bg", "bulgarian"}, {"lt", "lithuanian"}, {"la", "latin"},
+	{"mi", "maori"}, {"ml", "malayalam"}, {"cy", "welsh"}, {"sk", "slovak"},
+	{"te", "telugu"}, {"fa", "persian"}, {"lv", "latvian"}, {"bn", "bengali"},
+	{"sr", "serbian"}, {"az", "azerbaijani"}, {"sl", "slovenian"}, {"kn", "kannada"},
+	{"et", "estonian"}, {"mk", "macedonian"}, {"br", "breton"}, {"eu", "basque"},
+	{"is", "icelandic"}, {"hy", "armenian"}, {"ne", "nepali"}, {"mn", "mongolian"},
+	{"bs", "bosnian"}, {"kk", "kazakh"}, {"sq", "albanian"}, {"sw", "swahili"},
+	{"gl", "galician"}, {"mr", "marathi"}, {"pa", "punjabi"}, {"si", "sinhala"},
+	{"km", "khmer"}, {"sn", "shona"}, {"yo", "yoruba"}, {"so", "somali"},
+	{"af", "afrikaans"}, {"oc", "occitan"}, {"ka", "georgian"}, {"be", "belarusian"},
+	{"tg", "tajik"}, {"sd", "sindhi"}, {"gu", "gujarati"}, {"am", "amharic"},
+	{"yi", "yiddish"}, {"lo", "lao"}, {"uz", "uzbek"}, {"fo", "faroese"},
+	{"ht", "haitian creole"}, {"ps", "pashto"}, {"tk", "turkmen"}, {"nn", "nynorsk"},
+	{"mt", "maltese"}, {"sa", "sanskrit"}, {"lb", "luxembourgish"}, {"my", "myanmar"},
+	{"bo", "tibetan"}, {"tl", "tagalog"}, {"mg", "malagasy"}, {"as", "assamese"},
+	{"tt", "tatar"}, {"haw", "hawaiian"}, {"ln", "lingala"}, {"ha", "hausa"},
+	{"ba", "bashkir"}, {"jw", "javanese"}, {"su", "sundanese"}, {"yue", "cantonese"},
+}
+
+// languageAliases maps other common names to language codes.
+var languageAliases = map[string]string{
+	"burmese":       "my",
+	"valencian":     "ca",
+	"flemish":       "nl",
+	"haitian":       "ht",
+	"letzeburgesch": "lb",
+	"pushto":        "ps",
+	"panjabi":       "pa",
+	"moldavian":     "ro",
+	"moldovan":      "ro",
+	"sinhalese":     "si",
+	"castilian":     "es",
+	"mandarin":      "zh",
+}
+
+// Languages returns every language known to Whisper in token order. Use
+// Whisper.Languages for the languages of a loaded model.
+func Languages() []Language {
+	return append([]Language(nil), languages...)
+}
+
+// LookupLanguage finds a language by code, such as "fr", or by English name,
+// such as "French". The lookup ignores case.
+func LookupLanguage(s string) (Language, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if code, ok := languageAliases[s]; ok {
+		s = code
+	}
+
+	for _, l := range languages {
+		if l.Code == s || l.Name == s {
+			return l, true
+		}
+	}
+
+	return Language{}, false
+}
+
+// Languages returns the languages supported by the model: English only for
+// English-only models, otherwise the first NumLanguages languages.
+func (w *Whisper) Languages() []Language {
+	if !w.IsMultilingual() {
+		return []Language{languages[0]}
+	}
+
+	n := min(max(w.NumLanguages(), 0), len(languages))
+	return append([]Language(nil), languages[:n]...)
+}
+
+// ValidateLanguage checks that the model can transcribe the language with
+// the given code.
+func (w *Whisper) ValidateLanguage(code string) error {
+	l, err := lookupCode(code)
+	if err != nil {
+		return err
+	}
+
+	for _, supported := range w.Languages() {
+		if supported.Code == code {
+			return nil
+		}
+	}
+
+	if !w.IsMultilingual() {
+		return fmt.Errorf("language %q (%s) is not supported by this English-only model", code, l.Name)
+	}
+	return fmt.Errorf("language %q (%s) is not supported by this model, which has %d languages", code, l.Name, w.NumLanguages())
+}
+
+// lookupCode finds a language by its exact code. Names, such as "french",
+// and codes in another case are rejected, since the code goes into the
+// language token as it is.
+func lookupCode(code string) (Language, error) {
+	l, ok := LookupLanguage(code)
+	if !ok || l.Code != code {
+		return Language{}, fmt.Errorf("unknown language code %q", code)
+	}
+
+	return l, nil
+}
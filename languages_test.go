@@ -0,0 +1,90 @@
+package ctranslate2ffi
+
+import "testing"
+
+func TestLookupLanguage(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{in: "en", want: "en", ok: true},
+		{in: "French", want: "fr", ok: true},
+		{in: "  GERMAN ", want: "de", ok: true},
+		{in: "FR", want: "fr", ok: true},
+		{in: "haitian creole", want: "ht", ok: true},
+		{in: "Mandarin", want: "zh", ok: true},
+		{in: "castilian", want: "es", ok: true},
+		{in: "burmese", want: "my", ok: true},
+		{in: "yue", want: "yue", ok: true},
+		{in: "Cantonese", want: "yue", ok: true},
+		{in: "jw", want: "jw", ok: true},
+		{in: "klingon", ok: false},
+		{in: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		l, ok := LookupLanguage(tt.in)
+		if ok != tt.ok || l.Code != tt.want {
+			t.Errorf("LookupLanguage(%q) = %q, %v, want %q, %v", tt.in, l.Code, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestLookupCode(t *testing.T) {
+	for _, code := range []string{"en", "fr", "haw", "yue"} {
+		if l, err := lookupCode(code); err != nil || l.Code != code {
+			t.Errorf("lookupCode(%q) = %q, %v", code, l.Code, err)
+		}
+	}
+
+	// Names and codes in another case are not codes
+	for _, s := range []string{"french", "English", "FR", " en", "klingon", ""} {
+		if _, err := lookupCode(s); err == nil {
+			t.Errorf("lookupCode(%q) succeeded", s)
+		}
+	}
+}
+
+func TestValidateLanguageRejectsNames(t *testing.T) {
+	// Names are rejected before the model is consulted
+	var w Whisper
+	for _, s := range []string{"french", "English", "FR"} {
+		if err := w.ValidateLanguage(s); err == nil {
+			t.Errorf("ValidateLanguage(%q) succeeded", s)
+		}
+	}
+}
+
+func TestLanguageTable(t *testing.T) {
+	// Whisper.Languages slices a prefix of the table by the number of
+	// languages of the model, so the order must match the vocabulary
+	if len(languages) != 100 {
+		t.Fatalf("language table has %d entries, want 100", len(languages))
+	}
+
+	pinned := map[int]string{0: "en", 1: "zh", 2: "de", 3: "es", 97: "jw", 98: "su", 99: "yue"}
+	for i, code := range pinned {
+		if languages[i].Code != code {
+			t.Errorf("languages[%d] = %q, want %q", i, languages[i].Code, code)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, l := range languages {
+		if seen[l.Code] || seen[l.Name] {
+			t.Errorf("duplicate language %q (%s)", l.Code, l.Name)
+		}
+		seen[l.Code], seen[l.Name] = true, true
+	}
+
+	for alias, code := range languageAliases {
+		if _, ok := LookupLanguage(code); !ok {
+			t.Errorf("alias %q maps to unknown code %q", alias, code)
+		}
+	}
+
+	if got := (Language{Code: "en"}).Token(); got != "<|en|>" {
+		t.Errorf("Token() = %q, want <|en|>", got)
+	}
+}
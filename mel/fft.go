@@ -0,0 +1,138 @@
+package mel
+
+import "math"
+
+// fft is a mixed-radix Cooley-Tukey FFT plan for a fixed size. It handles
+// any size by recursing over its factors, radix 4 and 2 first, so NFFT=400
+// is transformed as 4*4*5*5 without zero padding. A plan is safe for
+// concurrent use.
+type fft struct {
+	n       int
+	factors []int        // radix and remaining length after each stage
+	twiddle []complex128 // exp(-2πik/n) for k in [0, n)
+}
+
+func newFFT(n int) *fft {
+	f := fft{
+		n:       n,
+		twiddle: make([]complex128, n),
+	}
+
+	for k := range f.twiddle {
+		angle := -2 * math.Pi * float64(k) / float64(n)
+		f.twiddle[k] = complex(math.Cos(angle), math.Sin(angle))
+	}
+
+	// Prefer radix 4, then 2, then odd primes
+	for m := n; m > 1; {
+		p := 4
+		for m%p != 0 {
+			switch p {
+			case 4:
+				p = 2
+			case 2:
+				p = 3
+			default:
+				p += 2
+			}
+			if p*p > m {
+				p = m
+			}
+		}
+		m /= p
+		f.factors = append(f.factors, p, m)
+	}
+
+	return &f
+}
+
+// transform computes the DFT of src into dst. Both must have length n and
+// scratch must have length of at least the largest radix.
+func (f *fft) transform(dst, src, scratch []complex128) {
+	if f.n == 1 {
+		dst[0] = src[0]
+		return
+	}
+
+	f.work(dst, src, 1, f.factors, scratch)
+}
+
+func (f *fft) work(dst, src []complex128, stride int, factors []int, scratch []complex128) {
+	p, m := factors[0], factors[1]
+
+	if m == 1 {
+		for k := 0; k < p; k++ {
+			dst[k] = src[k*stride]
+		}
+	} else {
+		for k := 0; k < p; k++ {
+			f.work(dst[k*m:], src[k*stride:], stride*p, factors[2:], scratch)
+		}
+	}
+
+	f.butterfly(dst, stride, p, m, scratch)
+}
+
+// butterfly combines p interleaved transforms of length m into one
+// transform of length p*m.
+func (f *fft) butterfly(dst []complex128, stride, p, m int, scratch []complex128) {
+	if p == 2 {
+		for u := 0; u < m; u++ {
+			t := dst[u+m] * f.twiddle[u*stride]
+			dst[u+m] = dst[u] - t
+			dst[u] += t
+		}
+		return
+	}
+
+	if p == 4 {
+		for u := 0; u < m; u++ {
+			s0 := dst[u+m] * f.twiddle[u*stride]
+			s1 := dst[u+2*m] * f.twiddle[2*u*stride]
+			s2 := dst[u+3*m] * f.twiddle[3*u*stride]
+
+			s5 := dst[u] - s1
+			s3 := s0 + s2
+			s4 := s0 - s2
+			s4 = complex(imag(s4), -real(s4)) // multiply by -i
+
+			dst[u] += s1
+			dst[u+2*m] = dst[u] - s3
+			dst[u] += s3
+			dst[u+m] = s5 + s4
+			dst[u+3*m] = s5 - s4
+		}
+		return
+	}
+
+	for u := 0; u < m; u++ {
+		for q := 0; q < p; q++ {
+			scratch[q] = dst[u+q*m]
+		}
+
+		for q1 := 0; q1 < p; q1++ {
+			k := u + q1*m
+			step := stride * k % f.n
+			sum := scratch[0]
+			idx := 0
+			for q := 1; q < p; q++ {
+				idx += step
+				if idx >= f.n {
+					idx -= f.n
+				}
+				sum += scratch[q] * f.twiddle[idx]
+			}
+			dst[k] = sum
+		}
+	}
+}
+
+// maxRadix returns the largest radix used by the plan.
+func (f *fft) maxRadix() int {
+	r := 1
+	for i := 0; i < len(f.factors); i += 2 {
+		r = max(r, f.factors[i])
+	}
+
+	return r
+}
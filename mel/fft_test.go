@@ -0,0 +1,85 @@
+package mel
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand/v2"
+	"testing"
+)
+
+// naiveDFT computes the DFT of src by its definition.
+func naiveDFT(src []complex128) []complex128 {
+	n := len(src)
+	dst := make([]complex128, n)
+	for k := range dst {
+		for j, x := range src {
+			angle := -2 * math.Pi * float64(j*k%n) / float64(n)
+			dst[k] += x * complex(math.Cos(angle), math.Sin(angle))
+		}
+	}
+
+	return dst
+}
+
+func TestFFT(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for _, n := range []int{1, 2, 3, 4, 5, 7, 8, 12, 16, 25, 49, 60, 97, 128, 400} {
+		src := make([]complex128, n)
+		for i := range src {
+			src[i] = complex(rng.Float64()*2-1, rng.Float64()*2-1)
+		}
+
+		plan := newFFT(n)
+		got := make([]complex128, n)
+		plan.transform(got, src, make([]complex128, plan.maxRadix()))
+
+		want := naiveDFT(src)
+		for k := range want {
+			if cmplx.Abs(got[k]-want[k]) > 1e-9*float64(n) {
+				t.Fatalf("n=%d: bin %d = %v, want %v", n, k, got[k], want[k])
+			}
+		}
+	}
+}
+
+func TestFFTFactors(t *testing.T) {
+	var radixes []int
+	for i := 0; i < len(stftPlan.factors); i += 2 {
+		radixes = append(radixes, stftPlan.factors[i])
+	}
+
+	want := []int{4, 4, 5, 5}
+	if len(radixes) != len(want) {
+		t.Fatalf("NFFT is transformed with radixes %v, want %v", radixes, want)
+	}
+	for i := range want {
+		if radixes[i] != want[i] {
+			t.Fatalf("NFFT is transformed with radixes %v, want %v", radixes, want)
+		}
+	}
+}
+
+func BenchmarkFFT(b *testing.B) {
+	src := make([]complex128, NFFT)
+	for i := range src {
+		src[i] = complex(math.Sin(float64(i)), 0)
+	}
+	dst := make([]complex128, NFFT)
+	scratch := make([]complex128, stftPlan.maxRadix())
+
+	for b.Loop() {
+		stftPlan.transform(dst, src, scratch)
+	}
+}
+
+func BenchmarkNaiveDFT(b *testing.B) {
+	src := make([]complex128, NFFT)
+	for i := range src {
+		src[i] = complex(math.Sin(float64(i)), 0)
+	}
+
+	for b.Loop() {
+		naiveDFT(src)
+	}
+}
@@ -0,0 +1,81 @@
+package mel
+
+import (
+	"math"
+	"sync"
+)
+
+// filterCache holds the filterbanks computed so far, keyed by the number of
+// mel bands. Whisper models use 80 or 128.
+var filterCache sync.Map
+
+// cachedFilters returns the filterbank for nMels, computing it once.
+func cachedFilters(nMels int) [][]float64 {
+	if f, ok := filterCache.Load(nMels); ok {
+		return f.([][]float64)
+	}
+
+	f, _ := filterCache.LoadOrStore(nMels, Filters(nMels))
+	return f.([][]float64)
+}
+
+// Filters returns the Slaney-style mel filterbank used by Whisper, with
+// nMels rows of NFFT/2+1 weights. It matches librosa.filters.mel with
+// sr=16000, n_fft=400, htk=False and norm="slaney".
+func Filters(nMels int) [][]float64 {
+	nBins := NFFT/2 + 1
+
+	// Center frequencies of the FFT bins
+	fftFreqs := make([]float64, nBins)
+	for i := range fftFreqs {
+		fftFreqs[i] = float64(i) * SampleRate / NFFT
+	}
+
+	// Band edges, evenly spaced on the mel scale between 0 and Nyquist
+	melMax := hzToMel(SampleRate / 2)
+	melFreqs := make([]float64, nMels+2)
+	for i := range melFreqs {
+		melFreqs[i] = melToHz(melMax * float64(i) / float64(nMels+1))
+	}
+
+	filters := make([][]float64, nMels)
+	for m := range filters {
+		lowerWidth := melFreqs[m+1] - melFreqs[m]
+		upperWidth := melFreqs[m+2] - melFreqs[m+1]
+
+		// Slaney normalization keeps the area of every filter constant
+		enorm := 2.0 / (melFreqs[m+2] - melFreqs[m])
+
+		filters[m] = make([]float64, nBins)
+		for k, f := range fftFreqs {
+			lower := (f - melFreqs[m]) / lowerWidth
+			upper := (melFreqs[m+2] - f) / upperWidth
+			filters[m][k] = max(0, min(lower, upper)) * enorm
+		}
+	}
+
+	return filters
+}
+
+// Slaney mel scale: linear below 1 kHz and logarithmic above.
+const (
+	fSP       = 200.0 / 3
+	minLogHz  = 1000.0
+	minLogMel = minLogHz / fSP
+)
+
+var logStep = math.Log(6.4) / 27.0
+
+func hzToMel(hz float64) float64 {
+	if hz < minLogHz {
+		return hz / fSP
+	}
+	return minLogMel + math.Log(hz/minLogHz)/logStep
+}
+
+func melToHz(mel float64) float64 {
+	if mel < minLogMel {
+		return mel * fSP
+	}
+	return minLogHz * math.Exp(logStep*(mel-minLogMel))
+}
@@ -0,0 +1,121 @@
+// Package mel computes the log-mel spectrogram features expected by Whisper
+// models. It reproduces the reference Whisper pipeline: a reflect-padded
+// STFT with a periodic Hann window, a Slaney-normalized mel filterbank, a
+// log10 clamped to 8 below the maximum and (x+4)/4 scaling. The STFT uses a
+// mixed-radix FFT and is spread across goroutines, one chunk of frames per
+// available CPU.
+package mel
+
+import (
+	"errors"
+	"math"
+
+	"github.com/ardanlabs/ctranslate2ffi"
+)
+
+// Whisper feature extraction parameters.
+const (
+	SampleRate = ctranslate2ffi.SampleRate
+	HopLength  = ctranslate2ffi.HopLength
+	NFFT       = 400                                    // 25ms window at 16kHz
+	NSamples   = ctranslate2ffi.ChunkFrames * HopLength // samples in a 30-second window
+	NFrames    = ctranslate2ffi.ChunkFrames             // frames in a 30-second window
+)
+
+// LogMel computes the log-mel spectrogram of 16kHz mono samples. The result
+// holds nMels rows of len(samples)/HopLength frames in row-major order, the
+// layout expected by Whisper.Transcribe.
+func LogMel(samples []float32, nMels int) ([]float32, error) {
+	if nMels <= 0 {
+		return nil, errors.New("number of mel bands must be positive")
+	}
+
+	nFrames := len(samples) / HopLength
+	if nFrames == 0 {
+		return nil, errors.New("not enough samples for a single frame")
+	}
+
+	power := powerSpectrogram(samples, nFrames)
+	filters := cachedFilters(nMels)
+	nBins := NFFT/2 + 1
+
+	// Apply the filterbank and take the log. Each filter only covers a few
+	// bins, so only its non-zero range is summed.
+	logSpec := make([]float64, nMels*nFrames)
+	maxVal := math.Inf(-1)
+	for m, filter := range filters {
+		lo, hi := nonZeroRange(filter)
+		filter = filter[lo:hi]
+
+		for f := 0; f < nFrames; f++ {
+			frame := power[f*nBins+lo : f*nBins+hi]
+
+			var energy float64
+			for k, w := range filter {
+				energy += w * frame[k]
+			}
+
+			v := math.Log10(max(energy, 1e-10))
+			logSpec[m*nFrames+f] = v
+			maxVal = max(maxVal, v)
+		}
+	}
+
+	// Clamp the dynamic range to 80dB and scale to roughly [-1, 1]
+	mel := make([]float32, len(logSpec))
+	for i, v := range logSpec {
+		mel[i] = float32((max(v, maxVal-8.0) + 4.0) / 4.0)
+	}
+
+	return mel, nil
+}
+
+// Features computes the features of a single 30-second window. samples are
+// padded or trimmed to 30 seconds and the result has the shape
+// [1, nMels, 3000] expected by Whisper.Generate.
+func Features(samples []float32, nMels int, device ctranslate2ffi.Device) (*ctranslate2ffi.StorageView, error) {
+	mel, err := LogMel(PadOrTrim(samples, NSamples), nMels)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctranslate2ffi.NewStorageViewFloat(mel, []int64{1, int64(nMels), NFrames}, device)
+}
+
+// nonZeroRange returns the bounds of the non-zero weights of a filter.
+func nonZeroRange(filter []float64) (int, int) {
+	lo, hi := 0, len(filter)
+	for lo < hi && filter[lo] == 0 {
+		lo++
+	}
+	for hi > lo && filter[hi-1] == 0 {
+		hi--
+	}
+
+	return lo, hi
+}
+
+// ModelLogMel computes the log-mel spectrogram of a whole recording with the
+// number of mel bands the model expects, 80 for most models and 128 for
+// large-v3.
+func ModelLogMel(w *ctranslate2ffi.Whisper, samples []float32) ([]float32, error) {
+	return LogMel(samples, w.NumMels())
+}
+
+// ModelFeatures computes the features of a single 30-second window with the
+// number of mel bands the model expects.
+func ModelFeatures(w *ctranslate2ffi.Whisper, samples []float32, device ctranslate2ffi.Device) (*ctranslate2ffi.StorageView, error) {
+	return Features(samples, w.NumMels(), device)
+}
+
+// PadOrTrim returns samples zero-padded or trimmed to length.
+func PadOrTrim(samples []float32, length int) []float32 {
+	if len(samples) == length {
+		return samples
+	}
+
+	out := make([]float32, length)
+	copy(out, samples)
+
+	return out
+}
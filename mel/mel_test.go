@@ -0,0 +1,128 @@
+package mel
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"strconv"
+	"testing"
+)
+
+// golden holds reference values generated by testdata/golden.py, a float64
+// transcription of whisper.audio.log_mel_spectrogram and of the
+// librosa.filters.mel call behind Whisper's mel_filters.npz.
+type golden struct {
+	Samples int                       `json:"samples"`
+	Filters map[string][][][2]float64 `json:"filters"`
+	LogMel  map[string][][]float64    `json:"log_mel"`
+}
+
+func loadGolden(t *testing.T) golden {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/golden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g golden
+	if err := json.Unmarshal(data, &g); err != nil {
+		t.Fatal(err)
+	}
+
+	return g
+}
+
+// testSignal is the signal of testdata/golden.py: 50ms of silence, then two
+// tones and a rising chirp.
+func testSignal(n int) []float32 {
+	samples := make([]float32, n)
+	for i := 800; i < n; i++ {
+		t := float64(i) / SampleRate
+		samples[i] = float32(0.4*math.Sin(2*math.Pi*440*t) +
+			0.2*math.Sin(2*math.Pi*2500*t+0.5) +
+			0.1*math.Sin(2*math.Pi*(1000+20000*t)*t))
+	}
+
+	return samples
+}
+
+func TestFilters(t *testing.T) {
+	g := loadGolden(t)
+
+	for _, nMels := range []int{80, 128} {
+		want := g.Filters[strconv.Itoa(nMels)]
+		got := Filters(nMels)
+		if len(got) != nMels || len(want) != nMels {
+			t.Fatalf("Filters(%d) has %d rows, want %d", nMels, len(got), len(want))
+		}
+
+		for m, row := range got {
+			if len(row) != NFFT/2+1 {
+				t.Fatalf("Filters(%d) row %d has %d weights, want %d", nMels, m, len(row), NFFT/2+1)
+			}
+
+			ref := make([]float64, len(row))
+			for _, w := range want[m] {
+				ref[int(w[0])] = w[1]
+			}
+			for k := range row {
+				if math.Abs(row[k]-ref[k]) > 1e-9 {
+					t.Errorf("Filters(%d)[%d][%d] = %v, want %v", nMels, m, k, row[k], ref[k])
+				}
+			}
+		}
+	}
+
+	// A value of Whisper's mel_filters.npz
+	if got := Filters(80)[0][1]; math.Abs(got-0.024862594) > 1e-8 {
+		t.Errorf("Filters(80)[0][1] = %v, want 0.024862594", got)
+	}
+}
+
+func TestLogMel(t *testing.T) {
+	g := loadGolden(t)
+	samples := testSignal(g.Samples)
+	nFrames := g.Samples / HopLength
+
+	for _, nMels := range []int{80, 128} {
+		want := g.LogMel[strconv.Itoa(nMels)]
+		got, err := LogMel(samples, nMels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != nMels*nFrames {
+			t.Fatalf("LogMel(%d) returned %d values, want %d", nMels, len(got), nMels*nFrames)
+		}
+
+		var worst float64
+		for m := range nMels {
+			for f := range nFrames {
+				worst = max(worst, math.Abs(float64(got[m*nFrames+f])-want[m][f]))
+			}
+		}
+		if worst > 1e-5 {
+			t.Errorf("LogMel(%d) differs from the reference by up to %g", nMels, worst)
+		}
+	}
+}
+
+func TestLogMelErrors(t *testing.T) {
+	if _, err := LogMel(make([]float32, NFFT), 0); err == nil {
+		t.Error("LogMel with no mel bands succeeded")
+	}
+	if _, err := LogMel(make([]float32, HopLength-1), 80); err == nil {
+		t.Error("LogMel with less than a frame of samples succeeded")
+	}
+}
+
+// BenchmarkLogMel computes the features of a 30-second window.
+func BenchmarkLogMel(b *testing.B) {
+	samples := testSignal(NSamples)
+
+	for b.Loop() {
+		if _, err := LogMel(samples, 80); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package mel
+
+import (
+	"math"
+	"runtime"
+	"sync"
+)
+
+var (
+	stftPlan   = newFFT(NFFT)
+	hannWindow = periodicHann(NFFT)
+)
+
+// powerSpectrogram computes |STFT|^2 of samples for nFrames frames. Frames
+// are centered on multiples of HopLength, with the signal reflect-padded by
+// NFFT/2 on both sides, and windowed with a periodic Hann window. The result
+// holds NFFT/2+1 bins per frame, frame after frame. Frames are split across
+// goroutines.
+func powerSpectrogram(samples []float32, nFrames int) []float64 {
+	nBins := NFFT/2 + 1
+	power := make([]float64, nFrames*nBins)
+
+	workers := min(runtime.GOMAXPROCS(0), nFrames)
+	chunk := (nFrames + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for start := 0; start < nFrames; start += chunk {
+		end := min(start+chunk, nFrames)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			frame := make([]complex128, NFFT)
+			spectrum := make([]complex128, NFFT)
+			scratch := make([]complex128, stftPlan.maxRadix())
+
+			for f := start; f < end; f++ {
+				offset := f*HopLength - NFFT/2
+				for j := range frame {
+					frame[j] = complex(float64(samples[reflect(offset+j, len(samples))])*hannWindow[j], 0)
+				}
+
+				stftPlan.transform(spectrum, frame, scratch)
+
+				out := power[f*nBins : (f+1)*nBins]
+				for k := range out {
+					re, im := real(spectrum[k]), imag(spectrum[k])
+					out[k] = re*re + im*im
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return power
+}
+
+// periodicHann returns a periodic Hann window of length n, matching
+// torch.hann_window(n).
+func periodicHann(n int) []float64 {
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 * (1 - math.Cos(2*math.Pi*float64(i)/float64(n)))
+	}
+
+	return window
+}
+
+// reflect maps an index outside [0, n) back into range by mirroring at the
+// edges without repeating the edge sample, like numpy's "reflect" mode.
+func reflect(i, n int) int {
+	if n == 1 {
+		return 0
+	}
+
+	period := 2 * (n - 1)
+	i %= period
+	if i < 0 {
+		i += period
+	}
+	if i >= n {
+		i = period - i
+	}
+
+	return i
+}
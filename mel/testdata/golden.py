@@ -0,0 +1,138 @@
+#!/usr/bin/env python3
+"""Generates golden.json, the reference values of mel_test.go.
+
+This is a float64 transcription of whisper.audio.log_mel_spectrogram
+(torch.stft with n_fft=400, hop_length=160, a periodic Hann window and
+reflect padding, dropping the last frame) and of librosa.filters.mel with
+sr=16000, n_fft=400, htk=False and norm="slaney", the source of Whisper's
+mel_filters.npz. It only needs the standard library:
+
+    python3 golden.py > golden.json
+"""
+
+import cmath
+import json
+import math
+import struct
+import sys
+
+SAMPLE_RATE = 16000
+N_FFT = 400
+HOP_LENGTH = 160
+N_SAMPLES = 4000
+
+
+def f32(x):
+    return struct.unpack("f", struct.pack("f", x))[0]
+
+
+def signal():
+    # Must match testSignal in mel_test.go: 50ms of silence, then two tones
+    # and a rising chirp
+    out = []
+    for i in range(N_SAMPLES):
+        t = i / SAMPLE_RATE
+        x = 0.0
+        if i >= 800:
+            x = (0.4 * math.sin(2 * math.pi * 440 * t)
+                 + 0.2 * math.sin(2 * math.pi * 2500 * t + 0.5)
+                 + 0.1 * math.sin(2 * math.pi * (1000 + 20000 * t) * t))
+        out.append(f32(x))
+    return out
+
+
+# librosa.filters.mel
+
+
+def hz_to_mel(f):
+    f_sp = 200.0 / 3
+    min_log_hz = 1000.0
+    min_log_mel = min_log_hz / f_sp
+    logstep = math.log(6.4) / 27.0
+    if f >= min_log_hz:
+        return min_log_mel + math.log(f / min_log_hz) / logstep
+    return f / f_sp
+
+
+def mel_to_hz(m):
+    f_sp = 200.0 / 3
+    min_log_hz = 1000.0
+    min_log_mel = min_log_hz / f_sp
+    logstep = math.log(6.4) / 27.0
+    if m >= min_log_mel:
+        return min_log_hz * math.exp(logstep * (m - min_log_mel))
+    return f_sp * m
+
+
+def mel_filters(n_mels):
+    n_bins = 1 + N_FFT // 2
+    fftfreqs = [i * (SAMPLE_RATE / 2) / (n_bins - 1) for i in range(n_bins)]
+
+    min_mel, max_mel = hz_to_mel(0.0), hz_to_mel(SAMPLE_RATE / 2)
+    mel_f = [mel_to_hz(min_mel + (max_mel - min_mel) * i / (n_mels + 1))
+             for i in range(n_mels + 2)]
+    fdiff = [mel_f[i + 1] - mel_f[i] for i in range(n_mels + 1)]
+
+    weights = []
+    for i in range(n_mels):
+        enorm = 2.0 / (mel_f[i + 2] - mel_f[i])
+        row = []
+        for f in fftfreqs:
+            lower = -(mel_f[i] - f) / fdiff[i]
+            upper = (mel_f[i + 2] - f) / fdiff[i + 1]
+            row.append(max(0.0, min(lower, upper)) * enorm)
+        weights.append(row)
+    return weights
+
+
+# whisper.audio.log_mel_spectrogram
+
+
+def reflect_pad(x, pad):
+    return x[pad:0:-1] + x + x[-2:-pad - 2:-1]
+
+
+def log_mel_spectrogram(samples, filters):
+    window = [0.5 * (1 - math.cos(2 * math.pi * i / N_FFT)) for i in range(N_FFT)]
+    padded = reflect_pad(samples, N_FFT // 2)
+    n_frames = len(samples) // HOP_LENGTH  # the last frame is dropped
+    n_bins = 1 + N_FFT // 2
+    twiddle = [cmath.exp(-2j * math.pi * k / N_FFT) for k in range(N_FFT)]
+
+    power = []
+    for t in range(n_frames):
+        frame = [padded[t * HOP_LENGTH + j] * window[j] for j in range(N_FFT)]
+        bins = []
+        for k in range(n_bins):
+            s = sum(frame[j] * twiddle[(j * k) % N_FFT] for j in range(N_FFT))
+            bins.append(abs(s) ** 2)
+        power.append(bins)
+
+    log_spec = []
+    for row in filters:
+        log_spec.append([
+            math.log10(max(sum(w * p for w, p in zip(row, power[t])), 1e-10))
+            for t in range(n_frames)
+        ])
+
+    top = max(max(row) for row in log_spec)
+    return [[(max(v, top - 8.0) + 4.0) / 4.0 for v in row] for row in log_spec]
+
+
+def sparse(filters):
+    # Only the non-zero weights of each filter, as [bin, weight] pairs
+    return [[[k, w] for k, w in enumerate(row) if w != 0] for row in filters]
+
+
+def main():
+    samples = signal()
+    golden = {"samples": N_SAMPLES, "filters": {}, "log_mel": {}}
+    for n_mels in (80, 128):
+        filters = mel_filters(n_mels)
+        golden["filters"][str(n_mels)] = sparse(filters)
+        golden["log_mel"][str(n_mels)] = log_mel_spectrogram(samples, filters)
+    json.dump(golden, sys.stdout, separators=(",", ":"))
+
+
+if __name__ == "__main__":
+    main()
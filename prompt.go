@@ -0,0 +1,80 @@
+package ctranslate2ffi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Whisper tasks.
+const (
+	TaskTranscribe = "transcribe"
+	TaskTranslate  = "translate"
+)
+
+// maxPrevToken is the number of conditioning tokens kept after
+// <|startofprev|>: half the decoder context, minus that token.
+const maxPrevToken = 223
+
+// WhisperPrompt describes the decoder prompt of a Whisper window and renders
+// it as the token list expected by Whisper.Generate.
+type WhisperPrompt struct {
+	// Language is the language code, such as "en". The language and task
+	// tokens are left out when it is empty, as English-only models expect.
+	Language string
+
+	// Task is TaskTranscribe or TaskTranslate. It defaults to
+	// TaskTranscribe. Translation needs a Language.
+	Task string
+
+	// Timestamps enables timestamp tokens in the output. When false, the
+	// prompt ends with <|notimestamps|>.
+	Timestamps bool
+
+	// InitialPrompt and PreviousText are tokens from the model vocabulary
+	// that condition the decoder after <|startofprev|>: InitialPrompt can
+	// hold spellings or a style to follow, PreviousText the text of the
+	// preceding windows. Only the last tokens that fit in the allowed
+	// prefix length are kept.
+	InitialPrompt []string
+	PreviousText  []string
+}
+
+// Tokens renders the prompt.
+func (p WhisperPrompt) Tokens() ([]string, error) {
+	task := p.Task
+	switch task {
+	case "":
+		task = TaskTranscribe
+	case TaskTranscribe, TaskTranslate:
+	default:
+		return nil, fmt.Errorf("unknown task %q, expected %q or %q", p.Task, TaskTranscribe, TaskTranslate)
+	}
+
+	// Without a language there is no task token, so the model would
+	// silently transcribe instead
+	if task == TaskTranslate && p.Language == "" {
+		return nil, errors.New("translation needs a language")
+	}
+
+	var tokens []string
+
+	// The conditioning text may use at most half of the decoder context
+	if prev := len(p.InitialPrompt) + len(p.PreviousText); prev > 0 {
+		context := make([]string, 0, prev)
+		context = append(context, p.InitialPrompt...)
+		context = append(context, p.PreviousText...)
+
+		tokens = append(tokens, "<|startofprev|>")
+		tokens = append(tokens, context[max(0, len(context)-maxPrevToken):]...)
+	}
+
+	tokens = append(tokens, "<|startoftranscript|>")
+	if p.Language != "" {
+		tokens = append(tokens, "<|"+p.Language+"|>", "<|"+task+"|>")
+	}
+	if !p.Timestamps {
+		tokens = append(tokens, "<|notimestamps|>")
+	}
+
+	return tokens, nil
+}
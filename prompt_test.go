@@ -0,0 +1,94 @@
+package ctranslate2ffi
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestWhisperPromptTokens(t *testing.T) {
+	// tokens returns n conditioning tokens named prefix0, prefix1, ...
+	tokens := func(prefix string, n int) []string {
+		out := make([]string, n)
+		for i := range out {
+			out[i] = fmt.Sprint(prefix, i)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		prompt WhisperPrompt
+		want   []string
+	}{
+		{
+			name:   "english-only",
+			prompt: WhisperPrompt{},
+			want:   []string{"<|startoftranscript|>", "<|notimestamps|>"},
+		},
+		{
+			name:   "language with timestamps",
+			prompt: WhisperPrompt{Language: "fr", Timestamps: true},
+			want:   []string{"<|startoftranscript|>", "<|fr|>", "<|transcribe|>"},
+		},
+		{
+			name:   "translate",
+			prompt: WhisperPrompt{Language: "de", Task: TaskTranslate},
+			want:   []string{"<|startoftranscript|>", "<|de|>", "<|translate|>", "<|notimestamps|>"},
+		},
+		{
+			name:   "initial prompt and previous text",
+			prompt: WhisperPrompt{Language: "en", Timestamps: true, InitialPrompt: []string{"ĠGlossary"}, PreviousText: []string{"ĠHello"}},
+			want:   []string{"<|startofprev|>", "ĠGlossary", "ĠHello", "<|startoftranscript|>", "<|en|>", "<|transcribe|>"},
+		},
+		{
+			name:   "conditioning truncated to its last tokens",
+			prompt: WhisperPrompt{Language: "en", Timestamps: true, InitialPrompt: tokens("i", 100), PreviousText: tokens("p", 200)},
+			want: append(append(append([]string{"<|startofprev|>"}, tokens("i", 100)[77:]...), tokens("p", 200)...),
+				"<|startoftranscript|>", "<|en|>", "<|transcribe|>"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.prompt.Tokens()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokens()\ngot  %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWhisperPromptTokensConditioningLength(t *testing.T) {
+	p := WhisperPrompt{Language: "en", PreviousText: make([]string, 500)}
+	got, err := p.Tokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// <|startofprev|>, the kept tokens, then the four task tokens
+	if n := len(got) - 5; n != 223 {
+		t.Errorf("kept %d conditioning tokens, want 223", n)
+	}
+}
+
+func TestWhisperPromptTokensErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt WhisperPrompt
+	}{
+		{name: "translate without language", prompt: WhisperPrompt{Task: TaskTranslate}},
+		{name: "unknown task", prompt: WhisperPrompt{Language: "en", Task: "summarize"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.prompt.Tokens(); err == nil {
+				t.Error("Tokens succeeded")
+			}
+		})
+	}
+}
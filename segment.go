@@ -0,0 +1,116 @@
+package ctranslate2ffi
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChunkDuration is the length of audio Whisper decodes in a single window.
+const ChunkDuration = 30 * time.Second
+
+// timestampToken matches Whisper timestamp tokens such as <|1.24|>.
+var timestampToken = regexp.MustCompile(`<\|(\d+(?:\.\d+)?)\|>`)
+
+// Segment is a span of transcribed text with its position in the audio.
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+
+	// Channel and Speaker identify the source of the segment when the
+	// channels of a recording are transcribed separately.
+	Channel int
+	Speaker string
+
+	// Words holds the timing of each word when word timestamps are
+	// enabled.
+	Words []Word
+}
+
+// TranscribeSegments transcribes a single audio window and splits the best
+// hypothesis into time-aligned segments using the timestamp tokens Whisper
+// emits. offset is the position of the window in the original audio and is
+// added to every segment. prompts must not contain <|notimestamps|>.
+func (w *Whisper) TranscribeSegments(features *StorageView, prompts []string, offset time.Duration, opts WhisperOptions) ([]Segment, error) {
+	for _, p := range prompts {
+		if p == "<|notimestamps|>" {
+			return nil, errors.New("timestamps are disabled by <|notimestamps|> in the prompt")
+		}
+	}
+
+	result, err := w.Generate(features, prompts, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Sequences) == 0 {
+		return nil, nil
+	}
+
+	return ParseSegments(result.Sequences[0], offset, ChunkDuration), nil
+}
+
+// ParseSegments splits a decoded Whisper sequence into segments delimited
+// by timestamp tokens. offset is added to every timestamp. Text that is not
+// closed by a timestamp ends at the window duration.
+func ParseSegments(text string, offset, duration time.Duration) []Segment {
+	var segments []Segment
+
+	var start time.Duration
+	var open bool
+	var buf strings.Builder
+
+	closeSegment := func(end time.Duration) {
+		if t := strings.TrimSpace(buf.String()); t != "" {
+			segments = append(segments, Segment{
+				Start: offset + start,
+				End:   offset + end,
+				Text:  t,
+			})
+		}
+		buf.Reset()
+		open = false
+	}
+
+	pos := 0
+	for _, m := range timestampToken.FindAllStringSubmatchIndex(text, -1) {
+		if chunk := text[pos:m[0]]; strings.TrimSpace(chunk) != "" {
+			open = true
+			buf.WriteString(chunk)
+		}
+		pos = m[1]
+
+		ts := parseTimestamp(text[m[2]:m[3]])
+
+		switch {
+		case open && strings.TrimSpace(buf.String()) != "":
+			closeSegment(ts)
+			start = ts
+
+		default:
+			// A timestamp with no text before it starts the next segment
+			start = ts
+			open = true
+		}
+	}
+
+	if chunk := text[pos:]; strings.TrimSpace(chunk) != "" {
+		buf.WriteString(chunk)
+		closeSegment(max(duration, start))
+	}
+
+	return segments
+}
+
+// parseTimestamp converts the seconds value of a timestamp token.
+func parseTimestamp(s string) time.Duration {
+	secs, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(secs * float64(time.Second)).Round(time.Millisecond)
+}
@@ -0,0 +1,61 @@
+package ctranslate2ffi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Segment
+	}{
+		{
+			name: "single segment",
+			text: "<|0.00|> Hello world<|2.50|>",
+			want: []Segment{
+				{Start: 10 * time.Second, End: 12500 * time.Millisecond, Text: "Hello world"},
+			},
+		},
+		{
+			name: "consecutive timestamp pairs",
+			text: "<|0.00|> One<|1.50|><|1.50|> Two<|3.00|><|3.40|> Three<|5.00|>",
+			want: []Segment{
+				{Start: 10 * time.Second, End: 11500 * time.Millisecond, Text: "One"},
+				{Start: 11500 * time.Millisecond, End: 13 * time.Second, Text: "Two"},
+				{Start: 13400 * time.Millisecond, End: 15 * time.Second, Text: "Three"},
+			},
+		},
+		{
+			name: "text not closed by a timestamp",
+			text: "<|0.00|> One<|1.50|><|1.50|> Two",
+			want: []Segment{
+				{Start: 10 * time.Second, End: 11500 * time.Millisecond, Text: "One"},
+				{Start: 11500 * time.Millisecond, End: 40 * time.Second, Text: "Two"},
+			},
+		},
+		{
+			name: "text without timestamps",
+			text: " Hello there",
+			want: []Segment{
+				{Start: 10 * time.Second, End: 40 * time.Second, Text: "Hello there"},
+			},
+		},
+		{
+			name: "timestamps without text",
+			text: "<|0.00|><|30.00|>",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseSegments(tt.text, 10*time.Second, ChunkDuration)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSegments(%q)\ngot  %+v\nwant %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
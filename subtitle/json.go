@@ -0,0 +1,79 @@
+package subtitle
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/ardanlabs/ctranslate2ffi"
+)
+
+// JSON writes the transcript as a JSON document:
+//
+//	{
+//	  "language": "en",
+//	  "segments": [
+//	    {"id": 0, "start": 0.0, "end": 2.5, "text": "Hello.", "channel": 0, "speaker": "agent",
+//	     "words": [{"start": 0.4, "end": 1.1, "word": " Hello.", "probability": 0.97}]}
+//	  ]
+//	}
+//
+// Times are in seconds. The speaker and words are left out when empty.
+type JSON struct {
+	Indent string
+}
+
+// JSONTranscript is the document written by JSON.
+type JSONTranscript struct {
+	Language string        `json:"language"`
+	Segments []JSONSegment `json:"segments"`
+}
+
+// JSONSegment is a segment of a JSONTranscript.
+type JSONSegment struct {
+	ID      int        `json:"id"`
+	Start   float64    `json:"start"`
+	End     float64    `json:"end"`
+	Text    string     `json:"text"`
+	Channel int        `json:"channel"`
+	Speaker string     `json:"speaker,omitempty"`
+	Words   []JSONWord `json:"words,omitempty"`
+}
+
+// JSONWord is a timed word of a JSONSegment.
+type JSONWord struct {
+	Start       float64 `json:"start"`
+	End         float64 `json:"end"`
+	Word        string  `json:"word"`
+	Probability float32 `json:"probability"`
+}
+
+// Write writes the transcript.
+func (j JSON) Write(w io.Writer, t *ctranslate2ffi.Transcript) error {
+	doc := JSONTranscript{
+		Language: t.Language,
+		Segments: make([]JSONSegment, len(t.Segments)),
+	}
+	for i, seg := range t.Segments {
+		doc.Segments[i] = JSONSegment{
+			ID:      i,
+			Start:   seg.Start.Seconds(),
+			End:     seg.End.Seconds(),
+			Text:    seg.Text,
+			Channel: seg.Channel,
+			Speaker: seg.Speaker,
+		}
+		for _, w := range seg.Words {
+			doc.Segments[i].Words = append(doc.Segments[i].Words, JSONWord{
+				Start:       w.Start.Seconds(),
+				End:         w.End.Seconds(),
+				Word:        w.Text,
+				Probability: w.Probability,
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", j.Indent)
+	return enc.Encode(doc)
+}
@@ -0,0 +1,31 @@
+package subtitle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ardanlabs/ctranslate2ffi"
+)
+
+// SRT writes SubRip subtitles. Cues of a segment with a speaker start with
+// the speaker name.
+type SRT struct {
+	Cues CueOptions
+}
+
+// Write writes the transcript.
+func (s SRT) Write(w io.Writer, t *ctranslate2ffi.Transcript) error {
+	bw := bufio.NewWriter(w)
+	for i, c := range s.Cues.cues(t.Segments, true) {
+		lines := c.lines
+		if c.speaker != "" {
+			lines = append([]string{c.speaker + ": " + lines[0]}, lines[1:]...)
+		}
+
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(c.start, ","), timestamp(c.end, ","), strings.Join(lines, "\n"))
+	}
+
+	return bw.Flush()
+}
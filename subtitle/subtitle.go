@@ -0,0 +1,163 @@
+// Package subtitle writes Whisper transcripts as SRT and WebVTT subtitles,
+// tab-separated values or JSON.
+package subtitle
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ardanlabs/ctranslate2ffi"
+)
+
+// Writer writes a transcript in a file format.
+type Writer interface {
+	Write(w io.Writer, t *ctranslate2ffi.Transcript) error
+}
+
+// Formats lists the names accepted by ForFormat.
+var Formats = []string{"srt", "vtt", "tsv", "json"}
+
+// ForFormat returns the writer for a format name, such as "srt", with
+// default options. The name is also the usual file extension.
+func ForFormat(name string) (Writer, error) {
+	switch strings.ToLower(name) {
+	case "srt":
+		return SRT{Cues: DefaultCueOptions()}, nil
+	case "vtt", "webvtt":
+		return VTT{Cues: DefaultCueOptions()}, nil
+	case "tsv":
+		return TSV{}, nil
+	case "json":
+		return JSON{Indent: "  "}, nil
+	}
+
+	return nil, fmt.Errorf("unknown format %q, expected one of %s", name, strings.Join(Formats, ", "))
+}
+
+// CueOptions controls how segments are laid out as subtitle cues. Segments
+// that do not fit in MaxLines lines of MaxLineLength characters, or that last
+// longer than MaxDuration, are split into several cues at word boundaries,
+// with the time divided in proportion to the length of the text. A zero
+// value disables the corresponding limit.
+type CueOptions struct {
+	MaxLineLength int
+	MaxLines      int
+	MaxDuration   time.Duration
+}
+
+// DefaultCueOptions returns the limits of common subtitle guidelines: two
+// lines of 42 characters on screen for at most 7 seconds.
+func DefaultCueOptions() CueOptions {
+	return CueOptions{
+		MaxLineLength: 42,
+		MaxLines:      2,
+		MaxDuration:   7 * time.Second,
+	}
+}
+
+// cue is a subtitle shown on screen.
+type cue struct {
+	start   time.Duration
+	end     time.Duration
+	lines   []string
+	speaker string
+}
+
+// cues lays out the segments of a transcript. When label is set, the first
+// line of every cue leaves room for a "speaker: " label.
+func (o CueOptions) cues(segments []ctranslate2ffi.Segment, label bool) []cue {
+	var cues []cue
+	for _, seg := range segments {
+		var indent int
+		if label && seg.Speaker != "" {
+			indent = len([]rune(seg.Speaker)) + 2
+		}
+		cues = append(cues, o.split(seg, indent)...)
+	}
+
+	return cues
+}
+
+// split lays out a segment as one or more cues, with indent characters
+// reserved at the start of the first line of each.
+func (o CueOptions) split(seg ctranslate2ffi.Segment, indent int) []cue {
+	words := strings.Fields(seg.Text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	// Time at the start of each word, in proportion to the characters
+	// before it
+	weights := make([]int, len(words)+1)
+	for i, w := range words {
+		weights[i+1] = weights[i] + len([]rune(w)) + 1
+	}
+	at := func(i int) time.Duration {
+		return seg.Start + (seg.End-seg.Start)*time.Duration(weights[i])/time.Duration(weights[len(words)])
+	}
+
+	var cues []cue
+	first := 0
+	for i := 1; i <= len(words); i++ {
+		if i < len(words) {
+			lines := wrap(words[first:i+1], o.MaxLineLength, indent)
+			tooLong := o.MaxLines > 0 && len(lines) > o.MaxLines
+			tooSlow := o.MaxDuration > 0 && at(i+1)-at(first) > o.MaxDuration
+			if !tooLong && !tooSlow {
+				continue
+			}
+		}
+
+		cues = append(cues, cue{
+			start:   at(first),
+			end:     at(i),
+			lines:   wrap(words[first:i], o.MaxLineLength, indent),
+			speaker: seg.Speaker,
+		})
+		first = i
+	}
+
+	return cues
+}
+
+// wrap fills lines of at most maxLen characters with words, the first line
+// starting after indent characters. A word longer than a line gets a line of
+// its own. maxLen of 0 puts every word on a single line.
+func wrap(words []string, maxLen, indent int) []string {
+	var lines []string
+	var line strings.Builder
+	var n int
+	for _, w := range words {
+		wn := len([]rune(w))
+		if n > 0 && maxLen > 0 && indent+n+1+wn > maxLen {
+			indent = 0
+			lines = append(lines, line.String())
+			line.Reset()
+			n = 0
+		}
+		if n > 0 {
+			line.WriteByte(' ')
+			n++
+		}
+		line.WriteString(w)
+		n += wn
+	}
+	if n > 0 {
+		lines = append(lines, line.String())
+	}
+
+	return lines
+}
+
+// timestamp formats d as hours:minutes:seconds with milliseconds after sep.
+func timestamp(d time.Duration, sep string) string {
+	d = max(d, 0).Round(time.Millisecond)
+	h := d / time.Hour
+	m := d % time.Hour / time.Minute
+	s := d % time.Minute / time.Second
+	ms := d % time.Second / time.Millisecond
+
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", h, m, s, sep, ms)
+}
@@ -0,0 +1,168 @@
+package subtitle
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ardanlabs/ctranslate2ffi"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// sample is a transcript with a speaker label, a segment too long for a
+// single cue, a segment too slow for one, text that WebVTT must escape and
+// word timestamps.
+var sample = &ctranslate2ffi.Transcript{
+	Language: "en",
+	Segments: []ctranslate2ffi.Segment{
+		{
+			Start:   0,
+			End:     2500 * time.Millisecond,
+			Text:    "Hello, thanks for calling.",
+			Speaker: "agent",
+			Words: []ctranslate2ffi.Word{
+				{Start: 400 * time.Millisecond, End: 1100 * time.Millisecond, Text: " Hello,", Probability: 0.97},
+				{Start: 1100 * time.Millisecond, End: 1600 * time.Millisecond, Text: " thanks", Probability: 0.91},
+				{Start: 1600 * time.Millisecond, End: 1800 * time.Millisecond, Text: " for", Probability: 0.99},
+				{Start: 1800 * time.Millisecond, End: 2500 * time.Millisecond, Text: " calling.", Probability: 0.95},
+			},
+		},
+		{
+			Start:   2500 * time.Millisecond,
+			End:     9 * time.Second,
+			Text:    "Hi, my order <#4521> never arrived & the tracking page says it was delivered to someone else entirely.",
+			Channel: 1,
+			Speaker: "customer",
+		},
+		{
+			Start: 3723 * time.Second,
+			End:   3741 * time.Second,
+			Text:  " Slow speech\tacross\nlines. ",
+		},
+	},
+}
+
+func TestWriters(t *testing.T) {
+	for _, name := range Formats {
+		t.Run(name, func(t *testing.T) {
+			w, err := ForFormat(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := w.Write(&buf, sample); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join("testdata", "sample."+name)
+			if *update {
+				if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("%s output differs from %s:\n%s", name, path, got)
+			}
+		})
+	}
+}
+
+func TestForFormat(t *testing.T) {
+	if w, err := ForFormat("WebVTT"); err != nil {
+		t.Error(err)
+	} else if _, ok := w.(VTT); !ok {
+		t.Errorf("ForFormat(WebVTT) = %T, want VTT", w)
+	}
+
+	if _, err := ForFormat("docx"); err == nil {
+		t.Error("ForFormat(docx) succeeded")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	words := []string{"the", "quick", "brown", "fox", "jumps"}
+
+	tests := []struct {
+		maxLen int
+		indent int
+		want   []string
+	}{
+		{maxLen: 0, want: []string{"the quick brown fox jumps"}},
+		{maxLen: 15, want: []string{"the quick brown", "fox jumps"}},
+		{maxLen: 15, indent: 6, want: []string{"the quick", "brown fox jumps"}},
+		{maxLen: 4, want: []string{"the", "quick", "brown", "fox", "jumps"}},
+	}
+
+	for _, tt := range tests {
+		if got := wrap(words, tt.maxLen, tt.indent); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrap(%d, %d) = %q, want %q", tt.maxLen, tt.indent, got, tt.want)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	seg := ctranslate2ffi.Segment{
+		Start: 10 * time.Second,
+		End:   20 * time.Second,
+		Text:  "aaaa bbbb cccc dddd",
+	}
+
+	tests := []struct {
+		name string
+		opts CueOptions
+		want []cue
+	}{
+		{
+			name: "no limits",
+			opts: CueOptions{},
+			want: []cue{{start: 10 * time.Second, end: 20 * time.Second, lines: []string{"aaaa bbbb cccc dddd"}}},
+		},
+		{
+			name: "line length",
+			opts: CueOptions{MaxLineLength: 9, MaxLines: 1},
+			want: []cue{
+				{start: 10 * time.Second, end: 15 * time.Second, lines: []string{"aaaa bbbb"}},
+				{start: 15 * time.Second, end: 20 * time.Second, lines: []string{"cccc dddd"}},
+			},
+		},
+		{
+			name: "duration",
+			opts: CueOptions{MaxDuration: 3 * time.Second},
+			want: []cue{
+				{start: 10 * time.Second, end: 12500 * time.Millisecond, lines: []string{"aaaa"}},
+				{start: 12500 * time.Millisecond, end: 15 * time.Second, lines: []string{"bbbb"}},
+				{start: 15 * time.Second, end: 17500 * time.Millisecond, lines: []string{"cccc"}},
+				{start: 17500 * time.Millisecond, end: 20 * time.Second, lines: []string{"dddd"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.split(seg, 0); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("split\ngot  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	d := time.Hour + 2*time.Minute + 3*time.Second + 4567*time.Microsecond
+	if got, want := timestamp(d, ","), "01:02:03,005"; got != want {
+		t.Errorf("timestamp = %q, want %q", got, want)
+	}
+	if got, want := timestamp(-time.Second, "."), "00:00:00.000"; got != want {
+		t.Errorf("timestamp of a negative duration = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,43 @@
+package subtitle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ardanlabs/ctranslate2ffi"
+)
+
+// TSV writes one segment per line with its start and end in integer
+// milliseconds and its text, after a header line. A speaker column is added
+// when any segment has a speaker.
+type TSV struct{}
+
+// tsvCleaner keeps text on a single field.
+var tsvCleaner = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+// Write writes the transcript.
+func (TSV) Write(w io.Writer, t *ctranslate2ffi.Transcript) error {
+	var speakers bool
+	for _, seg := range t.Segments {
+		speakers = speakers || seg.Speaker != ""
+	}
+
+	bw := bufio.NewWriter(w)
+	if speakers {
+		bw.WriteString("start\tend\ttext\tspeaker\n")
+	} else {
+		bw.WriteString("start\tend\ttext\n")
+	}
+
+	for _, seg := range t.Segments {
+		fmt.Fprintf(bw, "%d\t%d\t%s", seg.Start.Milliseconds(), seg.End.Milliseconds(), tsvCleaner.Replace(seg.Text))
+		if speakers {
+			fmt.Fprintf(bw, "\t%s", tsvCleaner.Replace(seg.Speaker))
+		}
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
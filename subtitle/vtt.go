@@ -0,0 +1,36 @@
+package subtitle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ardanlabs/ctranslate2ffi"
+)
+
+// VTT writes WebVTT subtitles. Cues of a segment with a speaker carry a
+// voice tag with the speaker name.
+type VTT struct {
+	Cues CueOptions
+}
+
+// vttEscaper escapes the characters that WebVTT cue text reserves.
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// Write writes the transcript.
+func (v VTT) Write(w io.Writer, t *ctranslate2ffi.Transcript) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+
+	for _, c := range v.Cues.cues(t.Segments, false) {
+		text := vttEscaper.Replace(strings.Join(c.lines, "\n"))
+		if c.speaker != "" {
+			text = "<v " + vttEscaper.Replace(c.speaker) + ">" + text
+		}
+
+		fmt.Fprintf(bw, "%s --> %s\n%s\n\n", timestamp(c.start, "."), timestamp(c.end, "."), text)
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,413 @@
+package ctranslate2ffi
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer is the byte-level BPE tokenizer of Whisper models. Tokens are
+// strings of bytes in which every byte is written as a printable character,
+// so a space is written Ġ and a newline Ċ. Decoding maps the characters back
+// to bytes; encoding merges bytes into tokens the way tiktoken does, where
+// the id of a token is also its merge rank.
+type Tokenizer struct {
+	tokens  []string
+	ids     map[string]int
+	special map[int]bool
+}
+
+// Vocabulary files looked up in a model directory, in order of preference.
+var vocabularyFiles = []string{"vocabulary.json", "vocabulary.txt", "tokenizer.json"}
+
+// LoadTokenizer loads the vocabulary of a CTranslate2 model directory from
+// vocabulary.json, vocabulary.txt or a Hugging Face tokenizer.json.
+func LoadTokenizer(modelDir string) (*Tokenizer, error) {
+	for _, name := range vocabularyFiles {
+		path := filepath.Join(modelDir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		var tokens []string
+		var err error
+		switch name {
+		case "vocabulary.json":
+			tokens, err = readVocabularyJSON(path)
+		case "vocabulary.txt":
+			tokens, err = readVocabularyText(path)
+		default:
+			tokens, err = readTokenizerJSON(path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		return NewTokenizer(tokens), nil
+	}
+
+	return nil, fmt.Errorf("no vocabulary found in %s", modelDir)
+}
+
+// NewTokenizer creates a tokenizer from the tokens of a vocabulary, indexed
+// by id. Tokens of the form <|...|> are special tokens.
+func NewTokenizer(tokens []string) *Tokenizer {
+	t := Tokenizer{
+		tokens:  tokens,
+		ids:     make(map[string]int, len(tokens)),
+		special: make(map[int]bool),
+	}
+
+	for id, tok := range tokens {
+		if _, ok := t.ids[tok]; !ok {
+			t.ids[tok] = id
+		}
+		if isSpecialToken(tok) {
+			t.special[id] = true
+		}
+	}
+
+	return &t
+}
+
+// Size returns the number of tokens in the vocabulary.
+func (t *Tokenizer) Size() int {
+	return len(t.tokens)
+}
+
+// Token returns the token with the given id.
+func (t *Tokenizer) Token(id int) (string, bool) {
+	if id < 0 || id >= len(t.tokens) {
+		return "", false
+	}
+
+	return t.tokens[id], true
+}
+
+// ID returns the id of a token.
+func (t *Tokenizer) ID(token string) (int, bool) {
+	id, ok := t.ids[token]
+	return id, ok
+}
+
+// IsSpecial reports whether a token is a special token such as
+// <|startoftranscript|>, <|en|> or the timestamp <|1.00|>.
+func (t *Tokenizer) IsSpecial(token string) bool {
+	if id, ok := t.ids[token]; ok {
+		return t.special[id]
+	}
+
+	return isSpecialToken(token)
+}
+
+// Decode converts token ids to text, leaving out special tokens.
+func (t *Tokenizer) Decode(ids []int) string {
+	tokens := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if tok, ok := t.Token(id); ok {
+			tokens = append(tokens, tok)
+		}
+	}
+
+	return t.DecodeTokens(tokens)
+}
+
+// DecodeTokens converts tokens to text, leaving out special tokens. Byte
+// sequences that are not valid UTF-8, such as a character cut between two
+// windows, are replaced with U+FFFD.
+func (t *Tokenizer) DecodeTokens(tokens []string) string {
+	var buf []byte
+	for _, tok := range tokens {
+		if t.IsSpecial(tok) {
+			continue
+		}
+		buf = appendTokenBytes(buf, tok)
+	}
+
+	return strings.ToValidUTF8(string(buf), "�")
+}
+
+// decodeBytes converts tokens to text with the byte mapping alone, for
+// sequences decoded without a vocabulary. Special tokens are left out.
+func decodeBytes(tokens []string) string {
+	var buf []byte
+	for _, tok := range tokens {
+		if !isSpecialToken(tok) {
+			buf = appendTokenBytes(buf, tok)
+		}
+	}
+
+	return strings.ToValidUTF8(string(buf), "�")
+}
+
+// DecodeSequence converts a sequence returned by Whisper, whose tokens are
+// separated by spaces, to text without special tokens.
+func (t *Tokenizer) DecodeSequence(seq string) string {
+	return t.DecodeTokens(strings.Fields(seq))
+}
+
+// Encode converts text to token ids. Special tokens in the text are encoded
+// as plain text. Prompts are usually encoded with a leading space, as in
+// " Glossary: CTranslate2, Whisper.", to match text in the middle of speech.
+func (t *Tokenizer) Encode(text string) ([]int, error) {
+	var ids []int
+	for _, piece := range splitPieces(text) {
+		for _, tok := range t.bpe(piece) {
+			id, ok := t.ids[tok]
+			if !ok {
+				return nil, fmt.Errorf("token %q is not in the vocabulary", tok)
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+// EncodeTokens converts text to tokens, the form Whisper.Generate and
+// WhisperPrompt expect.
+func (t *Tokenizer) EncodeTokens(text string) ([]string, error) {
+	ids, err := t.Encode(text)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]string, len(ids))
+	for i, id := range ids {
+		tokens[i] = t.tokens[id]
+	}
+
+	return tokens, nil
+}
+
+// bpe splits a pre-tokenized piece into tokens by repeatedly merging the
+// adjacent pair whose merged token has the lowest id.
+func (t *Tokenizer) bpe(piece string) []string {
+	parts := make([]string, 0, len(piece))
+	for i := 0; i < len(piece); i++ {
+		parts = append(parts, string(byteEncoder[piece[i]]))
+	}
+
+	for len(parts) > 1 {
+		best, bestID := -1, 0
+		for i := 0; i < len(parts)-1; i++ {
+			id, ok := t.ids[parts[i]+parts[i+1]]
+			if ok && !t.special[id] && (best < 0 || id < bestID) {
+				best, bestID = i, id
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+
+	return parts
+}
+
+// byteEncoder maps every byte to the character that stands for it in tokens:
+// printable bytes stand for themselves and the others are shifted past 255.
+var byteEncoder, byteDecoder = byteMaps()
+
+func byteMaps() ([256]rune, map[rune]byte) {
+	var enc [256]rune
+	dec := make(map[rune]byte, 256)
+
+	n := 0
+	for b := 0; b < 256; b++ {
+		if ('!' <= b && b <= '~') || (0xA1 <= b && b <= 0xAC) || (0xAE <= b && b <= 0xFF) {
+			enc[b] = rune(b)
+		} else {
+			enc[b] = rune(256 + n)
+			n++
+		}
+		dec[enc[b]] = byte(b)
+	}
+
+	return enc, dec
+}
+
+// appendTokenBytes appends the bytes a token stands for. Characters outside
+// the byte mapping are kept as they are.
+func appendTokenBytes(buf []byte, tok string) []byte {
+	for _, r := range tok {
+		if b, ok := byteDecoder[r]; ok {
+			buf = append(buf, b)
+			continue
+		}
+		buf = utf8.AppendRune(buf, r)
+	}
+
+	return buf
+}
+
+func isSpecialToken(tok string) bool {
+	return len(tok) > 4 && strings.HasPrefix(tok, "<|") && strings.HasSuffix(tok, "|>")
+}
+
+// splitPieces splits text the way the GPT-2 pattern does before BPE:
+// contractions, letters, numbers and other symbols, each with an optional
+// leading space, and runs of whitespace. A run of whitespace followed by
+// text leaves its last space to the text.
+func splitPieces(text string) []string {
+	var pieces []string
+
+	for i := 0; i < len(text); {
+		n := matchPiece(text[i:])
+
+		// Give the last space of a whitespace run to the following piece
+		if i+n < len(text) && strings.TrimFunc(text[i:i+n], unicode.IsSpace) == "" {
+			last, size := utf8.DecodeLastRuneInString(text[i : i+n])
+			if n > size {
+				pieces = append(pieces, text[i:i+n-size])
+				i += n - size
+				n = size
+			}
+			if last == ' ' {
+				n += matchPiece(text[i+n:])
+			}
+		}
+
+		pieces = append(pieces, text[i:i+n])
+		i += n
+	}
+
+	return pieces
+}
+
+var contractions = []string{"'s", "'t", "'re", "'ve", "'m", "'ll", "'d"}
+
+// matchPiece returns the length of the piece at the start of s.
+func matchPiece(s string) int {
+	for _, c := range contractions {
+		if strings.HasPrefix(s, c) {
+			return len(c)
+		}
+	}
+
+	r, _ := utf8.DecodeRuneInString(s)
+	if unicode.IsSpace(r) && r != ' ' {
+		return spanOf(s, 0, unicode.IsSpace)
+	}
+
+	// Optional leading space
+	start := 0
+	if r == ' ' {
+		if len(s) == 1 {
+			return 1
+		}
+		start = 1
+		r, _ = utf8.DecodeRuneInString(s[1:])
+	}
+
+	switch {
+	case unicode.IsLetter(r):
+		return spanOf(s, start, unicode.IsLetter)
+	case unicode.IsNumber(r):
+		return spanOf(s, start, unicode.IsNumber)
+	case !unicode.IsSpace(r):
+		return spanOf(s, start, func(r rune) bool {
+			return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+	}
+
+	return spanOf(s, 0, unicode.IsSpace)
+}
+
+// spanOf returns the end of the run of characters matching f in s starting
+// at start.
+func spanOf(s string, start int, f func(rune) bool) int {
+	i := start
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !f(r) {
+			break
+		}
+		i += size
+	}
+
+	return i
+}
+
+func readVocabularyJSON(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []string
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func readVocabularyText(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tokens []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		tokens = append(tokens, scanner.Text())
+	}
+
+	return tokens, scanner.Err()
+}
+
+// readTokenizerJSON reads the vocabulary and added tokens of a Hugging Face
+// tokenizer.json.
+func readTokenizerJSON(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Model struct {
+			Vocab map[string]int `json:"vocab"`
+		} `json:"model"`
+		AddedTokens []struct {
+			ID      int    `json:"id"`
+			Content string `json:"content"`
+		} `json:"added_tokens"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Model.Vocab) == 0 {
+		return nil, errors.New("no vocabulary in model")
+	}
+
+	byID := make(map[int]string, len(file.Model.Vocab)+len(file.AddedTokens))
+	size := 0
+	for tok, id := range file.Model.Vocab {
+		byID[id] = tok
+		size = max(size, id+1)
+	}
+	for _, tok := range file.AddedTokens {
+		byID[tok.ID] = tok.Content
+		size = max(size, tok.ID+1)
+	}
+
+	tokens := make([]string, size)
+	for id, tok := range byID {
+		if id >= 0 {
+			tokens[id] = tok
+		}
+	}
+
+	return tokens, nil
+}
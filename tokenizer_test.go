@@ -0,0 +1,171 @@
+package ctranslate2ffi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// Merges of the fixture vocabulary, in rank order. Every merge joins two
+// tokens that come before it.
+var fixtureMerges = []string{
+	"Ġt", "he", "Ġthe", "ll", "Ġw", "or", "Ġwor", "ld", "Ġworld", "'s", "ĠĠ", "ĊĊ",
+}
+
+// fixtureTokenizer returns a tokenizer whose vocabulary holds the 256 byte
+// tokens, ids 0 to 255 in byte order, then fixtureMerges and a few special
+// tokens.
+func fixtureTokenizer() *Tokenizer {
+	tokens := make([]string, 0, 256+len(fixtureMerges)+3)
+	for b := 0; b < 256; b++ {
+		tokens = append(tokens, string(byteEncoder[b]))
+	}
+	tokens = append(tokens, fixtureMerges...)
+	tokens = append(tokens, "<|endoftext|>", "<|startoftranscript|>", "<|0.00|>")
+
+	return NewTokenizer(tokens)
+}
+
+func TestSplitPieces(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Hello world", want: []string{"Hello", " world"}},
+		{text: "I'm here's", want: []string{"I", "'m", " here", "'s"}},
+		{text: "they'll've'd", want: []string{"they", "'ll", "'ve", "'d"}},
+		{text: "a  b", want: []string{"a", " ", " b"}},
+		{text: "a    b", want: []string{"a", "   ", " b"}},
+		{text: "a\nb", want: []string{"a", "\n", "b"}},
+		{text: "a\n\nb", want: []string{"a", "\n", "\n", "b"}},
+		{text: "a \n b", want: []string{"a", " \n", " b"}},
+		{text: "end   ", want: []string{"end", "   "}},
+		{text: "x123 45", want: []string{"x", "123", " 45"}},
+		{text: "hi!!! ok", want: []string{"hi", "!!!", " ok"}},
+		{text: "café naïve", want: []string{"café", " naïve"}},
+		{text: "日本語 テスト", want: []string{"日本語", " テスト"}},
+		{text: " ", want: []string{" "}},
+	}
+
+	for _, tt := range tests {
+		if got := splitPieces(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPieces(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizerEncodeTokens(t *testing.T) {
+	tok := fixtureTokenizer()
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "Hello world", want: []string{"H", "e", "ll", "o", "Ġworld"}},
+		{text: " the", want: []string{"Ġthe"}},
+		{text: "the cat's", want: []string{"t", "he", "Ġ", "c", "a", "t", "'s"}},
+		{text: "a   b", want: []string{"a", "ĠĠ", "Ġ", "b"}},
+		{text: "a\n\nb", want: []string{"a", "Ċ", "Ċ", "b"}},
+		{text: "\n\n", want: []string{"ĊĊ"}},
+	}
+
+	for _, tt := range tests {
+		got, err := tok.EncodeTokens(tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("EncodeTokens(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizerRoundTrip(t *testing.T) {
+	tok := fixtureTokenizer()
+
+	texts := []string{
+		"Hello world",
+		" the world's longest river isn't the Nile, they'll say.",
+		"two  spaces,   three and trailing   ",
+		"line one\nline two\n\n\tindented\r\n",
+		"café, naïve, façade",
+		"日本語のテキスト",
+		"emoji 👋🏽 and symbols ©®™ €100",
+		"<|endoftext|> is plain text here",
+	}
+
+	for _, text := range texts {
+		ids, err := tok.Encode(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tok.Decode(ids); got != text {
+			t.Errorf("Decode(Encode(%q)) = %q", text, got)
+		}
+	}
+}
+
+func TestTokenizerDecodeSplitCharacter(t *testing.T) {
+	tok := fixtureTokenizer()
+
+	// 日 is three bytes, so it takes three byte tokens
+	tokens, err := tok.EncodeTokens("日")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 3 {
+		t.Fatalf("EncodeTokens(%q) = %q, want 3 byte tokens", "日", tokens)
+	}
+
+	if got := tok.DecodeTokens(tokens); got != "日" {
+		t.Errorf("DecodeTokens(%q) = %q, want %q", tokens, got, "日")
+	}
+	if got := tok.DecodeTokens(tokens[:2]); !strings.ContainsRune(got, utf8.RuneError) {
+		t.Errorf("DecodeTokens of a partial character = %q, want U+FFFD", got)
+	}
+}
+
+func TestTokenizerSpecialTokens(t *testing.T) {
+	tok := fixtureTokenizer()
+
+	seq := "<|startoftranscript|> <|0.00|> ĠHello Ġworld <|endoftext|>"
+	if got, want := tok.DecodeSequence(seq), " Hello world"; got != want {
+		t.Errorf("DecodeSequence(%q) = %q, want %q", seq, got, want)
+	}
+
+	if !tok.IsSpecial("<|endoftext|>") || !tok.IsSpecial("<|12.34|>") || tok.IsSpecial("Ġworld") {
+		t.Error("IsSpecial misclassifies tokens")
+	}
+}
+
+func TestLoadTokenizer(t *testing.T) {
+	tokens := fixtureTokenizer().tokens
+
+	dir := t.TempDir()
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "vocabulary.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := LoadTokenizer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.Size() != len(tokens) {
+		t.Errorf("Size() = %d, want %d", tok.Size(), len(tokens))
+	}
+	if id, ok := tok.ID("Ġworld"); !ok || id != 264 {
+		t.Errorf("ID(Ġworld) = %d, %v, want 264", id, ok)
+	}
+
+	if _, err := LoadTokenizer(t.TempDir()); err == nil {
+		t.Error("LoadTokenizer of a directory without a vocabulary succeeded")
+	}
+}
@@ -0,0 +1,409 @@
+package ctranslate2ffi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ardanlabs/ctranslate2ffi/vad"
+)
+
+// Whisper feature extraction parameters.
+const (
+	SampleRate  = 16000 // audio sample rate expected by Whisper
+	HopLength   = 160   // audio samples between mel frames
+	ChunkFrames = 3000  // mel frames in a 30-second window
+	FrameRate   = SampleRate / HopLength
+)
+
+// TranscribeOptions holds options for long-form transcription.
+type TranscribeOptions struct {
+	// Language is the language code, such as "en". It is detected from
+	// the first window when empty on multilingual models.
+	Language string
+
+	// Task is either TaskTranscribe or TaskTranslate.
+	Task string
+
+	// InitialPrompt holds tokens from the model vocabulary that condition
+	// the first window, such as spellings of names or a style to follow.
+	// It keeps conditioning later windows along with the previous text
+	// when ConditionOnPreviousText is set.
+	InitialPrompt []string
+
+	// ConditionOnPreviousText feeds the text of the previous windows to
+	// the decoder as a prompt, which keeps the style consistent across
+	// windows.
+	ConditionOnPreviousText bool
+
+	// Speech restricts decoding to the given regions of the audio, such as
+	// the output of a vad.Detector. The regions are decoded back to back
+	// and segment timestamps are mapped back to the original audio. A nil
+	// slice decodes everything; an empty one means there is no speech.
+	Speech []vad.Region
+
+	// ChannelSpeech holds the speech regions of each channel for
+	// TranscribeChannels. When set, it takes precedence over Speech.
+	ChannelSpeech [][]vad.Region
+
+	// Temperatures are the sampling temperatures tried in turn when the
+	// output of a window fails the quality checks below. A temperature of
+	// 0 uses Whisper.BeamSize beam search; higher temperatures sample from
+	// the full distribution. When empty, every window is decoded once with
+	// the Whisper options as they are.
+	Temperatures []float32
+
+	// CompressionRatioThreshold fails a decode whose text compresses more
+	// than this ratio, which is a sign of repetition loops. Zero disables
+	// the check.
+	CompressionRatioThreshold float32
+
+	// LogProbThreshold fails a decode whose average log probability per
+	// token is below the value it points to. Nil disables the check; zero
+	// is a valid threshold.
+	LogProbThreshold *float32
+
+	// NoSpeechThreshold skips windows whose no-speech probability is above
+	// this value, unless the average log probability is above
+	// LogProbThreshold. Zero disables the check.
+	NoSpeechThreshold float32
+
+	// Tokenizer decodes the text of the segments. Without it, segment text
+	// holds the raw tokens of the model, separated by spaces.
+	Tokenizer *Tokenizer
+
+	// WordTimestamps aligns every window with its text to time each word
+	// of the segments. It needs a Tokenizer and a library that exports
+	// ct2_whisper_align.
+	WordTimestamps bool
+
+	// Whisper holds the decoding options used for every window.
+	Whisper WhisperOptions
+}
+
+// DefaultTranscribeOptions returns sensible default options.
+func DefaultTranscribeOptions() TranscribeOptions {
+	logProbThreshold := float32(-1.0)
+
+	return TranscribeOptions{
+		Task:                      TaskTranscribe,
+		ConditionOnPreviousText:   true,
+		Temperatures:              []float32{0, 0.2, 0.4, 0.6, 0.8, 1.0},
+		CompressionRatioThreshold: 2.4,
+		LogProbThreshold:          &logProbThreshold,
+		NoSpeechThreshold:         0.6,
+		Whisper:                   DefaultWhisperOptions(),
+	}
+}
+
+// Transcript holds the result of long-form transcription.
+type Transcript struct {
+	Language string
+	Segments []Segment
+}
+
+// Transcribe transcribes a log-mel spectrogram of any length. mel holds
+// NumMels rows of frames in row-major order. The audio is decoded in
+// 30-second windows; after each window the transcriber seeks to the last
+// complete segment so speech cut at a window boundary is decoded again in
+// the next window. Windows that fail the quality checks of opts are decoded
+// again at higher temperatures, and windows without speech are skipped.
+func (w *Whisper) Transcribe(mel []float32, opts TranscribeOptions) (*Transcript, error) {
+	if w.handle == 0 {
+		return nil, errors.New("whisper model is closed")
+	}
+
+	nMels := w.NumMels()
+	if nMels <= 0 || len(mel) == 0 || len(mel)%nMels != 0 {
+		return nil, fmt.Errorf("mel length %d is not a multiple of %d mel bands", len(mel), nMels)
+	}
+	nFrames := len(mel) / nMels
+
+	if opts.Language != "" {
+		if err := w.ValidateLanguage(opts.Language); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.WordTimestamps && opts.Tokenizer == nil {
+		return nil, errors.New("word timestamps need a tokenizer")
+	}
+	if opts.WordTimestamps && !ct2WhisperAlignLoaded {
+		return nil, errors.New("word timestamps need alignment, which is not supported by this library")
+	}
+
+	if opts.Speech == nil {
+		return w.transcribe(mel, nMels, nFrames, opts)
+	}
+
+	speech, chunks := speechMel(mel, nMels, nFrames, opts.Speech)
+	if len(chunks) == 0 {
+		return &Transcript{Language: opts.Language}, nil
+	}
+
+	tr, err := w.transcribe(speech, nMels, len(speech)/nMels, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tr.Segments {
+		seg := &tr.Segments[i]
+		seg.Start = chunks.original(seg.Start, false)
+		seg.End = chunks.original(seg.End, true)
+		for j := range seg.Words {
+			seg.Words[j].Start = chunks.original(seg.Words[j].Start, false)
+			seg.Words[j].End = chunks.original(seg.Words[j].End, true)
+		}
+	}
+
+	return tr, nil
+}
+
+// transcribe runs the windowed decoding loop over mel.
+func (w *Whisper) transcribe(mel []float32, nMels, nFrames int, opts TranscribeOptions) (*Transcript, error) {
+	tr := &Transcript{Language: opts.Language}
+	initialPrompt := opts.InitialPrompt
+	var prevTokens []string
+	floor := melFloor(mel)
+
+	for seek := 0; seek < nFrames; {
+		segmentFrames := min(ChunkFrames, nFrames-seek)
+		offset := framesToDuration(seek)
+		duration := framesToDuration(segmentFrames)
+
+		features, err := w.encodeWindow(mel, nMels, nFrames, seek, floor)
+		if err != nil {
+			return nil, err
+		}
+
+		if tr.Language == "" {
+			tr.Language = "en"
+			if w.IsMultilingual() {
+				langs, err := w.DetectLanguage(features)
+				if err != nil {
+					features.Close()
+					return nil, err
+				}
+				if len(langs) > 0 {
+					tr.Language = langs[0].Language
+				}
+			}
+		}
+
+		prompt := WhisperPrompt{
+			Task:          opts.Task,
+			Timestamps:    true,
+			InitialPrompt: initialPrompt,
+			PreviousText:  prevTokens,
+		}
+		if w.IsMultilingual() {
+			prompt.Language = tr.Language
+		}
+
+		prompts, err := prompt.Tokens()
+		if err != nil {
+			features.Close()
+			return nil, err
+		}
+
+		decoded, err := w.decodeWindow(features, prompts, opts)
+		if err != nil {
+			features.Close()
+			return nil, err
+		}
+
+		if decoded.silent {
+			features.Close()
+			seek += segmentFrames
+			continue
+		}
+
+		segments, advance := windowSegments(decoded.sequence, offset, duration)
+		kept := segments[:0]
+		var keptTokens [][]string
+		for _, seg := range segments {
+			seg.End = min(seg.End, offset+duration)
+			if seg.Start >= seg.End {
+				continue
+			}
+			tokens := sequenceTokens(seg.Text)
+			if opts.ConditionOnPreviousText {
+				prevTokens = append(prevTokens, tokens...)
+			}
+			kept = append(kept, seg)
+			keptTokens = append(keptTokens, tokens)
+		}
+
+		if opts.WordTimestamps && len(kept) > 0 {
+			err = w.segmentWords(features, opts.Tokenizer, prompt, kept, keptTokens, segmentFrames, offset)
+		}
+		features.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, seg := range kept {
+			if opts.Tokenizer != nil {
+				seg.Text = strings.TrimSpace(opts.Tokenizer.DecodeSequence(seg.Text))
+			}
+			tr.Segments = append(tr.Segments, seg)
+		}
+
+		if resetsConditioning(opts, decoded.temperature) {
+			initialPrompt, prevTokens = nil, nil
+		}
+
+		seek += max(1, min(segmentFrames, durationToFrames(advance)))
+	}
+
+	return tr, nil
+}
+
+// speechChunk is a speech region copied into the concatenated spectrogram.
+type speechChunk struct {
+	start  time.Duration // position in the concatenated spectrogram
+	origin time.Duration // position in the original audio
+	length time.Duration
+}
+
+type speechChunks []speechChunk
+
+// original maps a time in the concatenated spectrogram back to the original
+// audio. An end time that falls on the boundary between two chunks belongs
+// to the first one.
+func (c speechChunks) original(t time.Duration, end bool) time.Duration {
+	i := 0
+	for j, ch := range c {
+		if ch.start < t || (!end && ch.start == t) {
+			i = j
+		}
+	}
+
+	return c[i].origin + min(t-c[i].start, c[i].length)
+}
+
+// speechMel copies the frames of mel covered by the speech regions into a
+// single spectrogram. Frames the regions only partly cover are kept, so no
+// speech is cut at region edges.
+func speechMel(mel []float32, nMels, nFrames int, regions []vad.Region) ([]float32, speechChunks) {
+	type span struct{ start, end int }
+	var spans []span
+	var total int
+	for _, r := range vad.Merge(regions, 0) {
+		start := max(0, durationToFrames(r.Start))
+		end := min(nFrames, durationToFrames(r.End+time.Second/FrameRate-1))
+		if start >= end {
+			continue
+		}
+		spans = append(spans, span{start, end})
+		total += end - start
+	}
+
+	out := make([]float32, nMels*total)
+	chunks := make(speechChunks, 0, len(spans))
+	pos := 0
+	for _, s := range spans {
+		n := s.end - s.start
+		for m := 0; m < nMels; m++ {
+			copy(out[m*total+pos:m*total+pos+n], mel[m*nFrames+s.start:m*nFrames+s.end])
+		}
+		chunks = append(chunks, speechChunk{
+			start:  framesToDuration(pos),
+			origin: framesToDuration(s.start),
+			length: framesToDuration(n),
+		})
+		pos += n
+	}
+
+	return out, chunks
+}
+
+// windowSegments returns the complete segments of a decoded window and how
+// far to advance in the audio. When the window ends in the middle of a
+// segment, the incomplete text is dropped and the window only advances to
+// the end of the last complete segment.
+func windowSegments(seq string, offset, duration time.Duration) ([]Segment, time.Duration) {
+	matches := timestampToken.FindAllStringSubmatchIndex(seq, -1)
+
+	// A single timestamp at the very end means the window was fully decoded
+	trimmed := strings.TrimRightFunc(seq, unicode.IsSpace)
+	singleEnding := len(matches) > 0 && matches[len(matches)-1][1] == len(trimmed)
+	if singleEnding && len(matches) > 1 {
+		prev, last := matches[len(matches)-2], matches[len(matches)-1]
+		singleEnding = strings.TrimSpace(seq[prev[1]:last[0]]) != ""
+	}
+
+	// Find the last pair of consecutive timestamps that ends a segment
+	lastPair := -1
+	for i := 1; i < len(matches); i++ {
+		if strings.TrimSpace(seq[matches[i-1][1]:matches[i][0]]) == "" {
+			lastPair = i - 1
+		}
+	}
+
+	if singleEnding || lastPair < 0 {
+		return ParseSegments(seq, offset, duration), duration
+	}
+
+	end := matches[lastPair]
+	return ParseSegments(seq[:end[1]], offset, duration), parseTimestamp(seq[end[2]:end[3]])
+}
+
+// sequenceTokens splits decoded text back into tokens. The C code joins the
+// tokens of a hypothesis with spaces, which byte-level BPE tokens never
+// contain. Timestamp tokens are dropped.
+func sequenceTokens(text string) []string {
+	return strings.Fields(timestampToken.ReplaceAllString(text, " "))
+}
+
+// encodeWindow runs the encoder on the window of mel starting at seek so the
+// output can be shared by language detection and decoding.
+func (w *Whisper) encodeWindow(mel []float32, nMels, nFrames, seek int, floor float32) (*StorageView, error) {
+	features, err := NewStorageViewFloat(melWindow(mel, nMels, nFrames, seek, floor), []int64{1, int64(nMels), ChunkFrames}, DeviceCPU)
+	if err != nil {
+		return nil, err
+	}
+	defer features.Close()
+
+	return w.Encode(features, false)
+}
+
+// melWindow copies ChunkFrames frames of mel starting at seek, padding past
+// the end of the audio with floor, the level of silence.
+func melWindow(mel []float32, nMels, nFrames, seek int, floor float32) []float32 {
+	window := make([]float32, nMels*ChunkFrames)
+	n := min(ChunkFrames, nFrames-seek)
+	for m := 0; m < nMels; m++ {
+		row := window[m*ChunkFrames : (m+1)*ChunkFrames]
+		copy(row[:n], mel[m*nFrames+seek:m*nFrames+seek+n])
+		for i := n; i < ChunkFrames; i++ {
+			row[i] = floor
+		}
+	}
+
+	return window
+}
+
+// melFloor returns the value silence takes in a normalized log-mel
+// spectrogram: log10 energies are clamped to 8 below their maximum and
+// scaled by 1/4, so silence sits 2 below the largest value. Whisper pads the
+// audio with silence before computing the spectrogram, which puts the
+// padding of the last window at this level rather than at zero.
+func melFloor(mel []float32) float32 {
+	top := mel[0]
+	for _, v := range mel[1:] {
+		top = max(top, v)
+	}
+
+	return top - 2
+}
+
+func framesToDuration(frames int) time.Duration {
+	return time.Duration(frames) * time.Second / FrameRate
+}
+
+func durationToFrames(d time.Duration) int {
+	return int(d * FrameRate / time.Second)
+}
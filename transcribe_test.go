@@ -0,0 +1,184 @@
+package ctranslate2ffi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ardanlabs/ctranslate2ffi/vad"
+)
+
+func TestWindowSegments(t *testing.T) {
+	const offset = 30 * time.Second
+
+	tests := []struct {
+		name     string
+		seq      string
+		duration time.Duration
+		want     []Segment
+		seek     time.Duration
+	}{
+		{
+			name:     "single timestamp ending",
+			seq:      "<|0.00|> Hello world<|2.50|>",
+			duration: ChunkDuration,
+			want: []Segment{
+				{Start: 30 * time.Second, End: 32500 * time.Millisecond, Text: "Hello world"},
+			},
+			seek: ChunkDuration,
+		},
+		{
+			name:     "segment cut at the window end",
+			seq:      "<|0.00|> First<|2.00|><|2.00|> Second part",
+			duration: ChunkDuration,
+			want: []Segment{
+				{Start: 30 * time.Second, End: 32 * time.Second, Text: "First"},
+			},
+			seek: 2 * time.Second,
+		},
+		{
+			name:     "consecutive timestamp pairs",
+			seq:      "<|0.00|> One<|1.50|><|1.50|> Two<|3.00|><|3.00|>",
+			duration: ChunkDuration,
+			want: []Segment{
+				{Start: 30 * time.Second, End: 31500 * time.Millisecond, Text: "One"},
+				{Start: 31500 * time.Millisecond, End: 33 * time.Second, Text: "Two"},
+			},
+			seek: 3 * time.Second,
+		},
+		{
+			name:     "text without timestamps",
+			seq:      " Hello there",
+			duration: 12 * time.Second,
+			want: []Segment{
+				{Start: 30 * time.Second, End: 42 * time.Second, Text: "Hello there"},
+			},
+			seek: 12 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, seek := windowSegments(tt.seq, offset, tt.duration)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("windowSegments(%q) segments\ngot  %+v\nwant %+v", tt.seq, got, tt.want)
+			}
+			if seek != tt.seek {
+				t.Errorf("windowSegments(%q) seek = %v, want %v", tt.seq, seek, tt.seek)
+			}
+		})
+	}
+}
+
+func TestMelWindowPadding(t *testing.T) {
+	const nMels, nFrames = 2, 10
+	mel := make([]float32, nMels*nFrames)
+	for i := range mel {
+		mel[i] = float32(i) / 10
+	}
+
+	floor := melFloor(mel)
+	if want := float32(1.9) - 2; floor != want {
+		t.Fatalf("melFloor = %v, want %v", floor, want)
+	}
+
+	window := melWindow(mel, nMels, nFrames, 4, floor)
+	for m := 0; m < nMels; m++ {
+		row := window[m*ChunkFrames : (m+1)*ChunkFrames]
+		for i := 0; i < nFrames-4; i++ {
+			if want := mel[m*nFrames+4+i]; row[i] != want {
+				t.Fatalf("mel %d frame %d = %v, want %v", m, i, row[i], want)
+			}
+		}
+		for i := nFrames - 4; i < ChunkFrames; i++ {
+			if row[i] != floor {
+				t.Fatalf("mel %d padding frame %d = %v, want %v", m, i, row[i], floor)
+			}
+		}
+	}
+}
+
+func TestSpeechMel(t *testing.T) {
+	const nMels, nFrames = 2, 1000
+	mel := make([]float32, nMels*nFrames)
+	for m := 0; m < nMels; m++ {
+		for f := 0; f < nFrames; f++ {
+			mel[m*nFrames+f] = float32(m*nFrames + f)
+		}
+	}
+
+	ms := time.Millisecond
+	regions := []vad.Region{
+		{Start: 5 * time.Second, End: 6005 * ms},         // ends within frame 600
+		{Start: 1 * time.Second, End: 2 * time.Second},   // listed out of order
+		{Start: 1500 * ms, End: 2500 * ms},               // overlaps the previous one
+		{Start: 3 * time.Second, End: 3 * time.Second},   // empty
+		{Start: 9500 * ms, End: 12 * time.Second},        // past the end of the audio
+		{Start: 11 * time.Second, End: 13 * time.Second}, // entirely past the end
+	}
+
+	got, chunks := speechMel(mel, nMels, nFrames, regions)
+
+	// Frames 100-249, 500-600 and 950-999
+	var frames []int
+	for _, r := range [][2]int{{100, 250}, {500, 601}, {950, 1000}} {
+		for f := r[0]; f < r[1]; f++ {
+			frames = append(frames, f)
+		}
+	}
+	total := len(frames)
+	if len(got) != nMels*total {
+		t.Fatalf("speechMel returned %d values, want %d", len(got), nMels*total)
+	}
+	for m := 0; m < nMels; m++ {
+		for i, f := range frames {
+			if want := float32(m*nFrames + f); got[m*total+i] != want {
+				t.Fatalf("mel %d frame %d = %v, want %v", m, i, got[m*total+i], want)
+			}
+		}
+	}
+
+	want := speechChunks{
+		{start: 0, origin: 1 * time.Second, length: 1500 * ms},
+		{start: 1500 * ms, origin: 5 * time.Second, length: 1010 * ms},
+		{start: 2510 * ms, origin: 9500 * ms, length: 500 * ms},
+	}
+	if !reflect.DeepEqual(chunks, want) {
+		t.Errorf("chunks\ngot  %+v\nwant %+v", chunks, want)
+	}
+}
+
+func TestSpeechChunksOriginal(t *testing.T) {
+	ms := time.Millisecond
+	chunks := speechChunks{
+		{start: 0, origin: 1 * time.Second, length: 1500 * ms},
+		{start: 1500 * ms, origin: 5 * time.Second, length: 1010 * ms},
+		{start: 2510 * ms, origin: 9500 * ms, length: 500 * ms},
+	}
+
+	tests := []struct {
+		name string
+		t    time.Duration
+		end  bool
+		want time.Duration
+	}{
+		{name: "start of the first chunk", t: 0, want: 1 * time.Second},
+		{name: "end at the start of the first chunk", t: 0, end: true, want: 1 * time.Second},
+		{name: "within the first chunk", t: 1 * time.Second, want: 2 * time.Second},
+		{name: "start on a chunk boundary", t: 1500 * ms, want: 5 * time.Second},
+		{name: "end on a chunk boundary", t: 1500 * ms, end: true, want: 2500 * ms},
+		{name: "within the second chunk", t: 2 * time.Second, end: true, want: 5500 * ms},
+		{name: "start on the last boundary", t: 2510 * ms, want: 9500 * ms},
+		{name: "end on the last boundary", t: 2510 * ms, end: true, want: 6010 * ms},
+		{name: "end of the audio", t: 3010 * ms, end: true, want: 10 * time.Second},
+		{name: "past the end of the audio", t: 3500 * ms, end: true, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chunks.original(tt.t, tt.end); got != tt.want {
+				t.Errorf("original(%v, %v) = %v, want %v", tt.t, tt.end, got, tt.want)
+			}
+		})
+	}
+}
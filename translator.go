@@ -2,6 +2,7 @@ package ctranslate2ffi
 
 import (
 	"errors"
+	"runtime"
 	"unsafe"
 )
 
@@ -112,16 +113,17 @@ func (t *Translator) Translate(tokens []string, opts TranslationOptions) (*Trans
 		return nil, errors.New("translator is closed")
 	}
 
-	// Convert tokens to C strings
-	cStrings := make([]*byte, len(tokens))
-	for i, tok := range tokens {
-		b := append([]byte(tok), 0)
-		cStrings[i] = &b[0]
+	if len(tokens) == 0 {
+		return nil, errors.New("tokens cannot be empty")
 	}
-	tokensPtr := uintptr(unsafe.Pointer(&cStrings[0]))
+
+	// Convert tokens to C strings
+	cTokens := cStrings(tokens)
+	tokensPtr := uintptr(unsafe.Pointer(&cTokens[0]))
 
 	var result Ct2translationresult
 	ret := Ct2TranslatorTranslate(t.handle, tokensPtr, uint64(len(tokens)), opts.toC(), &result)
+	runtime.KeepAlive(cTokens)
 	if ret != 0 {
 		errMsg := Ct2GetLastError()
 		if errMsg == "" {
@@ -130,9 +132,68 @@ func (t *Translator) Translate(tokens []string, opts TranslationOptions) (*Trans
 		return nil, errors.New(errMsg)
 	}
 
-	tr := &TranslationResult{}
-	// TODO: Properly extract hypotheses from the raw pointers
+	tr := newTranslationResult(&result, opts)
 
 	Ct2TranslationResultFree(&result)
 	return tr, nil
 }
+
+// TranslateBatch translates a batch of token sequences in a single call so
+// CTranslate2 can batch them internally. It returns one result per source,
+// in the same order as sources.
+func (t *Translator) TranslateBatch(sources [][]string, opts TranslationOptions) ([]TranslationResult, error) {
+	if t.handle == 0 {
+		return nil, errors.New("translator is closed")
+	}
+
+	if len(sources) == 0 {
+		return nil, errors.New("sources cannot be empty")
+	}
+
+	// Convert sources to a ragged array of C strings
+	cSources, lengths := cStringBatch(sources)
+	sourcesPtr := uintptr(unsafe.Pointer(&cSources[0]))
+	lengthsPtr := uintptr(unsafe.Pointer(&lengths[0]))
+
+	results := make([]Ct2translationresult, len(sources))
+	ret := Ct2TranslatorTranslateBatch(t.handle, sourcesPtr, lengthsPtr, uint64(len(sources)), opts.toC(), &results[0])
+	runtime.KeepAlive(cSources)
+	runtime.KeepAlive(lengths)
+	if ret != 0 {
+		errMsg := Ct2GetLastError()
+		if errMsg == "" {
+			errMsg = "batch translation failed"
+		}
+		return nil, errors.New(errMsg)
+	}
+
+	trs := make([]TranslationResult, len(results))
+	for i := range results {
+		trs[i] = *newTranslationResult(&results[i], opts)
+		Ct2TranslationResultFree(&results[i])
+	}
+
+	return trs, nil
+}
+
+// newTranslationResult copies the hypotheses and scores out of a C result.
+// It must be called before the result is freed.
+func newTranslationResult(result *Ct2translationresult, opts TranslationOptions) *TranslationResult {
+	tr := &TranslationResult{}
+
+	if result.Hypotheses != nil && result.HypothesesLengths != nil && result.NumHypotheses > 0 {
+		hyps := unsafe.Slice(result.Hypotheses, result.NumHypotheses)
+		lengths := unsafe.Slice(result.HypothesesLengths, result.NumHypotheses)
+
+		tr.Hypotheses = make([][]string, result.NumHypotheses)
+		for i := range hyps {
+			tr.Hypotheses[i] = goStrings(hyps[i], lengths[i])
+		}
+	}
+
+	if opts.ReturnScores {
+		tr.Scores = goFloats(result.Scores, result.NumScores)
+	}
+
+	return tr
+}
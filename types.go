@@ -27,7 +27,7 @@ var FFITypeCt2modelconfig = ffi.NewType(
 )
 
 type Ct2stringarray struct {
-	Strings string
+	Strings **byte
 	Count   uint64
 }
 
@@ -37,7 +37,7 @@ var FFITypeCt2stringarray = ffi.NewType(
 )
 
 type Ct2floatarray struct {
-	Values uintptr
+	Values *float32
 	Count  uint64
 }
 
@@ -79,9 +79,9 @@ var FFITypeCt2whisperoptions = ffi.NewType(
 )
 
 type Ct2whisperresult struct {
-	Sequences    string
+	Sequences    **byte
 	NumSequences uint64
-	Scores       uintptr
+	Scores       *float32
 	NumScores    uint64
 	NoSpeechProb float32
 }
@@ -94,6 +94,22 @@ var FFITypeCt2whisperresult = ffi.NewType(
 	&ffi.TypeFloat,
 )
 
+type Ct2whisperalignmentresult struct {
+	TextTokenIndices *uint64
+	TimeIndices      *uint64
+	NumAlignments    uint64
+	TextTokenProbs   *float32
+	NumTextTokens    uint64
+}
+
+var FFITypeCt2whisperalignmentresult = ffi.NewType(
+	&ffi.TypePointer,
+	&ffi.TypePointer,
+	&ffi.TypeUint64,
+	&ffi.TypePointer,
+	&ffi.TypeUint64,
+)
+
 type Ct2translationoptions struct {
 	BeamSize            uint64
 	Patience            float32
@@ -137,10 +153,10 @@ var FFITypeCt2translationoptions = ffi.NewType(
 )
 
 type Ct2translationresult struct {
-	Hypotheses        string
-	HypothesesLengths uintptr
+	Hypotheses        ***byte
+	HypothesesLengths *uint64
 	NumHypotheses     uint64
-	Scores            uintptr
+	Scores            *float32
 	NumScores         uint64
 }
 
@@ -187,10 +203,10 @@ var FFITypeCt2generationoptions = ffi.NewType(
 )
 
 type Ct2generationresult struct {
-	Sequences       string
-	SequenceLengths uintptr
+	Sequences       ***byte
+	SequenceLengths *uint64
 	NumSequences    uint64
-	Scores          uintptr
+	Scores          *float32
 	NumScores       uint64
 }
 
@@ -0,0 +1,190 @@
+package vad
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// EnergyDetector is a voice activity detector in the style of Rabiner and
+// Sambur. Frames whose energy rises OnsetDB above the noise floor start a
+// speech region, which lasts until the energy falls back below OffsetDB
+// above the floor; the gap between the two thresholds keeps short dips from
+// splitting words. Region edges are then extended over neighbouring frames
+// with a high zero-crossing rate, which catches unvoiced sounds such as
+// fricatives that carry little energy.
+type EnergyDetector struct {
+	// FrameDuration is the length of the analysis frames.
+	FrameDuration time.Duration
+
+	// OnsetDB and OffsetDB are the energy levels in decibels above the
+	// noise floor at which speech starts and ends.
+	OnsetDB  float64
+	OffsetDB float64
+
+	// SilenceDB is the level in dBFS below which frames are never speech,
+	// so recordings with a digitally silent floor do not pick up hiss.
+	SilenceDB float64
+
+	// ZeroCrossingRate is the fraction of sample pairs that must change
+	// sign for a frame next to a region to be treated as unvoiced speech.
+	ZeroCrossingRate float64
+
+	// MaxExtension limits how far regions are extended by zero crossings.
+	MaxExtension time.Duration
+
+	// MinSpeech drops regions shorter than this, MinSilence merges regions
+	// separated by less than this and Padding widens every region.
+	MinSpeech  time.Duration
+	MinSilence time.Duration
+	Padding    time.Duration
+}
+
+// NewEnergyDetector returns an energy detector with sensible defaults.
+func NewEnergyDetector() *EnergyDetector {
+	return &EnergyDetector{
+		FrameDuration:    30 * time.Millisecond,
+		OnsetDB:          12,
+		OffsetDB:         6,
+		SilenceDB:        -60,
+		ZeroCrossingRate: 0.25,
+		MaxExtension:     250 * time.Millisecond,
+		MinSpeech:        250 * time.Millisecond,
+		MinSilence:       500 * time.Millisecond,
+		Padding:          200 * time.Millisecond,
+	}
+}
+
+// noiseFloorPercentile is the fraction of frames assumed to be quieter than
+// the background noise of a recording.
+const noiseFloorPercentile = 0.1
+
+// Detect returns the speech regions of mono samples.
+func (d *EnergyDetector) Detect(samples []float32, sampleRate int) ([]Region, error) {
+	if sampleRate <= 0 {
+		return nil, errors.New("vad: sample rate must be positive")
+	}
+
+	frameLen := int(d.FrameDuration * time.Duration(sampleRate) / time.Second)
+	if frameLen < 2 {
+		return nil, errors.New("vad: frame duration is too short")
+	}
+
+	nFrames := len(samples) / frameLen
+	if nFrames == 0 {
+		return []Region{}, nil
+	}
+
+	energy := make([]float64, nFrames)
+	zcr := make([]float64, nFrames)
+	for f := range energy {
+		energy[f], zcr[f] = frameStats(samples[f*frameLen : (f+1)*frameLen])
+	}
+
+	floor := percentile(energy, noiseFloorPercentile)
+	peak := percentile(energy, 1)
+	if peak < d.SilenceDB {
+		return []Region{}, nil
+	}
+
+	// Without quiet frames to measure the noise floor against, the whole
+	// recording is speech
+	if peak-floor < d.OnsetDB && floor >= d.SilenceDB {
+		return []Region{{End: d.frameTime(nFrames, sampleRate, frameLen)}}, nil
+	}
+
+	onset := max(floor+d.OnsetDB, d.SilenceDB)
+	offset := max(floor+d.OffsetDB, d.SilenceDB)
+
+	// Energy with hysteresis, in frames
+	type span struct{ start, end int }
+	var spans []span
+	active := false
+	for f, e := range energy {
+		switch {
+		case !active && e >= onset:
+			spans = append(spans, span{start: f})
+			active = true
+		case active && e < offset:
+			spans[len(spans)-1].end = f
+			active = false
+		}
+	}
+	if active {
+		spans[len(spans)-1].end = nFrames
+	}
+
+	// Extend the edges over unvoiced frames, never into the previous or
+	// next region
+	maxExt := int(d.MaxExtension / d.FrameDuration)
+	for i := range spans {
+		lo := 0
+		if i > 0 {
+			lo = spans[i-1].end
+		}
+		for n := 0; n < maxExt && spans[i].start > lo && zcr[spans[i].start-1] >= d.ZeroCrossingRate && energy[spans[i].start-1] >= d.SilenceDB; n++ {
+			spans[i].start--
+		}
+
+		hi := nFrames
+		if i < len(spans)-1 {
+			hi = spans[i+1].start
+		}
+		for n := 0; n < maxExt && spans[i].end < hi && zcr[spans[i].end] >= d.ZeroCrossingRate && energy[spans[i].end] >= d.SilenceDB; n++ {
+			spans[i].end++
+		}
+	}
+
+	regions := make([]Region, 0, len(spans))
+	for _, s := range spans {
+		regions = append(regions, Region{
+			Start: d.frameTime(s.start, sampleRate, frameLen),
+			End:   d.frameTime(s.end, sampleRate, frameLen),
+		})
+	}
+	regions = Merge(regions, d.MinSilence)
+
+	// Drop short bursts, then pad what is left
+	end := time.Duration(len(samples)) * time.Second / time.Duration(sampleRate)
+	kept := regions[:0]
+	for _, r := range regions {
+		if r.Duration() < d.MinSpeech {
+			continue
+		}
+		r.Start = max(0, r.Start-d.Padding)
+		r.End = min(end, r.End+d.Padding)
+		kept = append(kept, r)
+	}
+
+	return append([]Region{}, Merge(kept, 0)...), nil
+}
+
+func (d *EnergyDetector) frameTime(frame, sampleRate, frameLen int) time.Duration {
+	return time.Duration(frame*frameLen) * time.Second / time.Duration(sampleRate)
+}
+
+// frameStats returns the energy of a frame in dBFS and the fraction of
+// consecutive samples that change sign.
+func frameStats(frame []float32) (float64, float64) {
+	var sum float64
+	var crossings int
+	for i, s := range frame {
+		sum += float64(s) * float64(s)
+		if i > 0 && (s >= 0) != (frame[i-1] >= 0) {
+			crossings++
+		}
+	}
+
+	db := 10 * math.Log10(sum/float64(len(frame))+1e-10)
+	return db, float64(crossings) / float64(len(frame)-1)
+}
+
+// percentile returns the value below which the fraction p of values fall.
+func percentile(values []float64, p float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	return sorted[int(p*float64(len(sorted)-1))]
+}
@@ -0,0 +1,127 @@
+package vad
+
+import (
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testRate = 16000
+
+// signal builds test audio from parts of noise and tone, each lasting the
+// given time. Every part carries noise of the given amplitude, and tone
+// parts add a 440Hz sine of amplitude 0.5.
+type part struct {
+	tone     bool
+	duration time.Duration
+}
+
+func signal(noise float32, parts ...part) []float32 {
+	rng := rand.New(rand.NewPCG(5, 6))
+
+	var samples []float32
+	for _, p := range parts {
+		n := int(p.duration * testRate / time.Second)
+		for i := 0; i < n; i++ {
+			var s float32
+			if noise > 0 {
+				s = (rng.Float32()*2 - 1) * noise
+			}
+			if p.tone {
+				s += float32(0.5 * math.Sin(2*math.Pi*440*float64(len(samples))/testRate))
+			}
+			samples = append(samples, s)
+		}
+	}
+
+	return samples
+}
+
+func TestEnergyDetector(t *testing.T) {
+	ms := time.Millisecond
+
+	tests := []struct {
+		name    string
+		samples []float32
+		want    []Region
+	}{
+		{
+			// Frames are 30ms, so the tone starts in the frame at 990ms and
+			// ends in the frame before 2010ms; regions are padded by 200ms
+			name:    "silence, tone, silence",
+			samples: signal(0, part{false, time.Second}, part{true, time.Second}, part{false, time.Second}),
+			want:    []Region{{Start: 790 * ms, End: 2210 * ms}},
+		},
+		{
+			// Noisy frames next to the tone cross zero often enough to be
+			// taken as unvoiced speech, up to 250ms, or 8 frames, each side
+			name:    "noise, tone, noise",
+			samples: signal(0.01, part{false, time.Second}, part{true, time.Second}, part{false, time.Second}),
+			want:    []Region{{Start: 550 * ms, End: 2450 * ms}},
+		},
+		{
+			name:    "short pause within speech",
+			samples: signal(0, part{false, time.Second}, part{true, time.Second}, part{false, 300 * ms}, part{true, time.Second}, part{false, time.Second}),
+			want:    []Region{{Start: 790 * ms, End: 3500 * ms}},
+		},
+		{
+			name: "long pause between speech",
+			samples: signal(0, part{false, time.Second}, part{true, time.Second}, part{false, 2 * time.Second},
+				part{true, time.Second}, part{false, time.Second}),
+			want: []Region{{Start: 790 * ms, End: 2210 * ms}, {Start: 3790 * ms, End: 5210 * ms}},
+		},
+		{
+			name:    "burst shorter than MinSpeech",
+			samples: signal(0, part{false, time.Second}, part{true, 100 * ms}, part{false, time.Second}),
+			want:    []Region{},
+		},
+		{
+			name:    "all silent",
+			samples: signal(0, part{false, 3 * time.Second}),
+			want:    []Region{},
+		},
+		{
+			name:    "all noise below the silence level",
+			samples: signal(0.0005, part{false, 3 * time.Second}),
+			want:    []Region{},
+		},
+		{
+			// Without quieter frames the whole recording is speech, up to
+			// the last whole frame
+			name:    "all speech",
+			samples: signal(0, part{true, time.Second}),
+			want:    []Region{{End: 990 * ms}},
+		},
+		{
+			name:    "shorter than a frame",
+			samples: signal(0, part{true, 20 * ms}),
+			want:    []Region{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewEnergyDetector().Detect(tt.samples, testRate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect = %#v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnergyDetectorErrors(t *testing.T) {
+	d := NewEnergyDetector()
+	if _, err := d.Detect(make([]float32, 100), 0); err == nil {
+		t.Error("Detect with a zero sample rate succeeded")
+	}
+
+	d.FrameDuration = time.Microsecond
+	if _, err := d.Detect(make([]float32, 100), testRate); err == nil {
+		t.Error("Detect with frames shorter than two samples succeeded")
+	}
+}
@@ -0,0 +1,55 @@
+// Package vad finds the regions of a recording that contain speech so the
+// transcriber can skip silence, where Whisper wastes compute and tends to
+// hallucinate text. Detectors are pluggable through the Detector interface;
+// EnergyDetector is a dependency-free detector based on frame energy and
+// zero-crossing rate.
+package vad
+
+import (
+	"sort"
+	"time"
+)
+
+// Region is a span of speech in a recording.
+type Region struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Duration returns the length of the region.
+func (r Region) Duration() time.Duration {
+	return r.End - r.Start
+}
+
+// Detector finds the speech regions in mono samples. The regions are sorted
+// and do not overlap. When there is no speech, Detect returns an empty slice
+// rather than nil, since a nil TranscribeOptions.Speech decodes everything.
+type Detector interface {
+	Detect(samples []float32, sampleRate int) ([]Region, error)
+}
+
+// Merge sorts regions and merges the ones that overlap or are separated by
+// less than gap.
+func Merge(regions []Region, gap time.Duration) []Region {
+	if len(regions) == 0 {
+		return nil
+	}
+
+	sorted := make([]Region, len(regions))
+	copy(sorted, regions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	merged := sorted[:1]
+	for _, r := range sorted[1:] {
+		last := &merged[len(merged)-1]
+		if r.Start-last.End < gap {
+			last.End = max(last.End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
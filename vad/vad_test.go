@@ -0,0 +1,64 @@
+package vad
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	s := time.Second
+
+	tests := []struct {
+		name    string
+		regions []Region
+		gap     time.Duration
+		want    []Region
+	}{
+		{name: "empty", regions: nil, want: nil},
+		{name: "single", regions: []Region{{1 * s, 2 * s}}, want: []Region{{1 * s, 2 * s}}},
+		{
+			name:    "unsorted",
+			regions: []Region{{5 * s, 6 * s}, {1 * s, 2 * s}},
+			want:    []Region{{1 * s, 2 * s}, {5 * s, 6 * s}},
+		},
+		{
+			name:    "overlapping",
+			regions: []Region{{1 * s, 3 * s}, {2 * s, 4 * s}},
+			want:    []Region{{1 * s, 4 * s}},
+		},
+		{
+			name:    "contained",
+			regions: []Region{{1 * s, 5 * s}, {2 * s, 3 * s}},
+			want:    []Region{{1 * s, 5 * s}},
+		},
+		{
+			name:    "touching without a gap",
+			regions: []Region{{1 * s, 2 * s}, {2 * s, 3 * s}},
+			want:    []Region{{1 * s, 2 * s}, {2 * s, 3 * s}},
+		},
+		{
+			name:    "closer than the gap",
+			regions: []Region{{1 * s, 2 * s}, {2400 * time.Millisecond, 3 * s}, {4 * s, 5 * s}},
+			gap:     500 * time.Millisecond,
+			want:    []Region{{1 * s, 3 * s}, {4 * s, 5 * s}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Merge(tt.regions, tt.gap); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeKeepsInput(t *testing.T) {
+	regions := []Region{{Start: 3 * time.Second, End: 4 * time.Second}, {Start: 1 * time.Second, End: 2 * time.Second}}
+	Merge(regions, time.Second)
+
+	if regions[0].Start != 3*time.Second {
+		t.Errorf("Merge reordered its input: %v", regions)
+	}
+}
@@ -2,6 +2,10 @@ package ctranslate2ffi
 
 import (
 	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
 	"unsafe"
 )
 
@@ -112,7 +116,9 @@ func (o WhisperOptions) toC() Ct2whisperoptions {
 	}
 }
 
-// WhisperResult holds the result of Whisper transcription.
+// WhisperResult holds the result of Whisper transcription for a single audio
+// window. Sequences holds one string per hypothesis, best first, and Scores
+// the matching scores when ReturnScores is set.
 type WhisperResult struct {
 	Sequences    []string
 	Scores       []float32
@@ -160,26 +166,62 @@ func (w *Whisper) NumLanguages() int {
 	return int(Ct2WhisperNumLanguages(w.handle))
 }
 
-// Generate transcribes audio features.
+// Encode runs the encoder on the mel features and returns its output. The
+// output can be passed in place of the mel features to Generate,
+// GenerateBatch, DetectLanguage and DetectLanguageBatch, which then skip the
+// encoder. When toCPU is set the output is copied to host memory.
+func (w *Whisper) Encode(features *StorageView, toCPU bool) (*StorageView, error) {
+	if w.handle == 0 {
+		return nil, errors.New("whisper model is closed")
+	}
+
+	if features.encoded {
+		return nil, errors.New("features are already encoded")
+	}
+
+	if _, err := w.featureShape(features); err != nil {
+		return nil, err
+	}
+
+	handle := Ct2WhisperEncode(w.handle, features.handle, toCPU)
+	if handle == 0 {
+		errMsg := Ct2GetLastError()
+		if errMsg == "" {
+			errMsg = "whisper encoding failed"
+		}
+		return nil, errors.New(errMsg)
+	}
+
+	return &StorageView{handle: handle, encoded: true}, nil
+}
+
+// Generate transcribes a single audio window. features can be either mel
+// features or the output of Encode, with a batch size of 1; batches are
+// transcribed with GenerateBatch.
 func (w *Whisper) Generate(features *StorageView, prompts []string, opts WhisperOptions) (*WhisperResult, error) {
 	if w.handle == 0 {
 		return nil, errors.New("whisper model is closed")
 	}
 
+	shape, err := w.featureShape(features)
+	if err != nil {
+		return nil, err
+	}
+	if shape[0] != 1 {
+		return nil, fmt.Errorf("generation expects a single audio window, got a batch of %d; use GenerateBatch", shape[0])
+	}
+
 	// Prepare prompts as C strings
 	var promptsPtr uintptr
 	numPrompts := uint64(len(prompts))
-	if len(prompts) > 0 {
-		cStrings := make([]*byte, len(prompts))
-		for i, p := range prompts {
-			b := append([]byte(p), 0)
-			cStrings[i] = &b[0]
-		}
-		promptsPtr = uintptr(unsafe.Pointer(&cStrings[0]))
+	cPrompts := cStrings(prompts)
+	if len(cPrompts) > 0 {
+		promptsPtr = uintptr(unsafe.Pointer(&cPrompts[0]))
 	}
 
 	var result Ct2whisperresult
 	ret := Ct2WhisperGenerate(w.handle, features.handle, promptsPtr, numPrompts, opts.toC(), &result)
+	runtime.KeepAlive(cPrompts)
 	if ret != 0 {
 		errMsg := Ct2GetLastError()
 		if errMsg == "" {
@@ -188,21 +230,182 @@ func (w *Whisper) Generate(features *StorageView, prompts []string, opts Whisper
 		return nil, errors.New(errMsg)
 	}
 
-	// Convert result - note: the C code joins tokens, so we get one string per sequence
+	wr := newWhisperResult(&result, opts)
+
+	Ct2WhisperResultFree(&result)
+	return wr, nil
+}
+
+// GenerateBatch transcribes a batch of audio windows in a single call.
+// features must have the shape [batch, n_mels, frames], or be the output of
+// Encode for such a batch, and prompts must hold one prompt per batch entry.
+// It returns one result per batch entry, in the same order as prompts.
+func (w *Whisper) GenerateBatch(features *StorageView, prompts [][]string, opts WhisperOptions) ([]WhisperResult, error) {
+	if w.handle == 0 {
+		return nil, errors.New("whisper model is closed")
+	}
+
+	if !ct2WhisperGenerateBatchLoaded {
+		return nil, errors.New("batch generation is not supported by this library")
+	}
+
+	shape, err := w.featureShape(features)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prompts) == 0 {
+		return nil, errors.New("batch cannot be empty")
+	}
+	if int64(len(prompts)) != shape[0] {
+		return nil, fmt.Errorf("got %d prompts for a batch of %d", len(prompts), shape[0])
+	}
+
+	// Convert prompts to a ragged array of C strings
+	cPrompts, lengths := cStringBatch(prompts)
+	promptsPtr := uintptr(unsafe.Pointer(&cPrompts[0]))
+	lengthsPtr := uintptr(unsafe.Pointer(&lengths[0]))
+
+	results := make([]Ct2whisperresult, len(prompts))
+	ret := Ct2WhisperGenerateBatch(w.handle, features.handle, promptsPtr, lengthsPtr, uint64(len(prompts)), opts.toC(), &results[0])
+	runtime.KeepAlive(cPrompts)
+	runtime.KeepAlive(lengths)
+	if ret != 0 {
+		errMsg := Ct2GetLastError()
+		if errMsg == "" {
+			errMsg = "whisper batch generation failed"
+		}
+		return nil, errors.New(errMsg)
+	}
+
+	wrs := make([]WhisperResult, len(results))
+	for i := range results {
+		wrs[i] = *newWhisperResult(&results[i], opts)
+		Ct2WhisperResultFree(&results[i])
+	}
+
+	return wrs, nil
+}
+
+// LanguageProbability holds the probability of a language detected in the
+// audio. Language is the bare language code, such as "en".
+type LanguageProbability struct {
+	Language    string
+	Probability float32
+}
+
+// DetectLanguage returns the languages detected in a single audio window,
+// most probable first. features can be either mel features or the output of
+// Encode.
+func (w *Whisper) DetectLanguage(features *StorageView) ([]LanguageProbability, error) {
+	batch, err := w.DetectLanguageBatch(features)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch[0], nil
+}
+
+// DetectLanguageBatch returns the languages detected in every batch entry of
+// features, each list sorted most probable first.
+func (w *Whisper) DetectLanguageBatch(features *StorageView) ([][]LanguageProbability, error) {
+	if w.handle == 0 {
+		return nil, errors.New("whisper model is closed")
+	}
+
+	shape, err := w.featureShape(features)
+	if err != nil {
+		return nil, err
+	}
+	batchSize := int(shape[0])
+
+	var languages Ct2stringarray
+	var probs Ct2floatarray
+	ret := Ct2WhisperDetectLanguage(w.handle, features.handle, &languages, &probs)
+	if ret != 0 {
+		errMsg := Ct2GetLastError()
+		if errMsg == "" {
+			errMsg = "whisper language detection failed"
+		}
+		return nil, errors.New(errMsg)
+	}
+
+	codes := goStrings(languages.Strings, languages.Count)
+	values := goFloats(probs.Values, probs.Count)
+	Ct2StringsFree(&languages)
+	Ct2FloatsFree(&probs)
+
+	if len(codes) != len(values) || len(codes)%batchSize != 0 {
+		return nil, fmt.Errorf("unexpected language detection result: %d languages, %d probabilities for a batch of %d", len(codes), len(values), batchSize)
+	}
+
+	// The C code flattens the per-entry results into a single array
+	perEntry := len(codes) / batchSize
+	result := make([][]LanguageProbability, batchSize)
+	for b := range result {
+		lps := make([]LanguageProbability, perEntry)
+		for i := range lps {
+			idx := b*perEntry + i
+			lps[i] = LanguageProbability{
+				Language:    strings.TrimSuffix(strings.TrimPrefix(codes[idx], "<|"), "|>"),
+				Probability: values[idx],
+			}
+		}
+		sort.SliceStable(lps, func(i, j int) bool {
+			return lps[i].Probability > lps[j].Probability
+		})
+		result[b] = lps
+	}
+
+	return result, nil
+}
+
+// featureShape returns the shape of features after checking that it is a
+// batch of mel features with the number of mel bands the model expects, or
+// the output of Encode.
+func (w *Whisper) featureShape(features *StorageView) ([]int64, error) {
+	shape, err := features.Shape()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shape) != 3 || shape[0] < 1 {
+		return nil, fmt.Errorf("features must have the shape [batch, n_mels, frames], got %v", shape)
+	}
+
+	if !features.encoded {
+		if nMels := int64(w.NumMels()); shape[1] != nMels {
+			return nil, fmt.Errorf("features have %d mel bands but the model expects %d", shape[1], nMels)
+		}
+	}
+
+	return shape, nil
+}
+
+// newWhisperResult copies the sequences and scores out of the C result of a
+// single batch entry. The C code joins the tokens of each hypothesis, so
+// there is one string per hypothesis, best first. It must be called before
+// the result is freed.
+func newWhisperResult(result *Ct2whisperresult, opts WhisperOptions) *WhisperResult {
 	wr := &WhisperResult{
-		NoSpeechProb: result.NoSpeechProb,
+		Sequences: goStrings(result.Sequences, result.NumSequences),
 	}
 
-	// TODO: Properly extract sequences and scores from the raw pointers
-	// This requires understanding the memory layout
+	if opts.ReturnScores {
+		wr.Scores = goFloats(result.Scores, result.NumScores)
+	}
 
-	Ct2WhisperResultFree(&result)
-	return wr, nil
+	if opts.ReturnNoSpeechProb {
+		wr.NoSpeechProb = result.NoSpeechProb
+	}
+
+	return wr
 }
 
 // StorageView wraps a CTranslate2 storage view (tensor).
 type StorageView struct {
-	handle Ct2storageview
+	handle  Ct2storageview
+	encoded bool // holds Whisper encoder output rather than mel features
 }
 
 // NewStorageViewFloat creates a storage view from float data.